@@ -0,0 +1,239 @@
+// Command fakeinfo stands in for the song info service. It serves the
+// details of the songs in a fixture file and can script how each song is
+// answered, so every path of creating a song can be exercised.
+//
+//	fakeinfo [-addr 0.0.0.0:8070] [-fixtures fixtures.json]
+//
+// A fixture file lists songs with their details and an optional script,
+// plus the script of songs it does not list, which answer 404 without one:
+//
+//	{
+//	  "songs": [
+//	    {"group": "Muse", "song": "Hysteria", "releaseDate": "01.12.2003", "text": "...", "link": "...",
+//	     "script": [{"status": 500}, {"latency": "2s"}, {"malformed": true}]}
+//	  ],
+//	  "unknown": [{"status": 404}]
+//	}
+//
+// Each request for a song answers with the next step of its script and the
+// last step repeats once the script runs out; a song without a script
+// always answers with its details. A step waits latency before answering
+// with status, 200 when unset, and sends JSON cut short when malformed is
+// set. POST /reset reads the fixture file again and restarts every script.
+package main
+
+import (
+	"client/server"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"musicservice/pkg/config"
+)
+
+// Fixtures is the content of a fixture file.
+type Fixtures struct {
+	Songs   []Fixture `json:"songs"`
+	Unknown []Step    `json:"unknown"`
+}
+
+// Fixture is a song with its details and how it is answered.
+type Fixture struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	Script      []Step `json:"script"`
+}
+
+// Step is one scripted answer.
+type Step struct {
+	Status    int      `json:"status"`
+	Latency   Duration `json:"latency"`
+	Malformed bool     `json:"malformed"`
+}
+
+// Duration is a time.Duration written as a string such as "250ms".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("latency must be a string such as \"250ms\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+type key struct {
+	group, song string
+}
+
+func keyOf(group, song string) key {
+	return key{group: strings.ToLower(strings.TrimSpace(group)), song: strings.ToLower(strings.TrimSpace(song))}
+}
+
+// Server serves the fixtures of a file. It implements the generated
+// api.ServerInterface.
+type Server struct {
+	logger *slog.Logger
+	path   string
+
+	mu      sync.Mutex
+	songs   map[key]Fixture
+	unknown []Step
+	calls   map[key]int
+}
+
+// NewServer returns a server for the fixtures of path.
+func NewServer(logger *slog.Logger, path string) (*Server, error) {
+	s := &Server{logger: logger, path: path}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the fixture file and restarts every script.
+func (s *Server) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var fixtures Fixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+
+	songs := make(map[key]Fixture, len(fixtures.Songs))
+	for i, f := range fixtures.Songs {
+		if strings.TrimSpace(f.Group) == "" || strings.TrimSpace(f.Song) == "" {
+			return fmt.Errorf("failed to read %s: song %d has no group or song", s.path, i)
+		}
+		songs[keyOf(f.Group, f.Song)] = f
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.songs, s.unknown, s.calls = songs, fixtures.Unknown, make(map[key]int)
+	return nil
+}
+
+// next returns the song asked for, whether it is listed, and the step of
+// its script that answers this call.
+func (s *Server) next(group, song string) (Fixture, bool, Step) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := keyOf(group, song)
+	fixture, ok := s.songs[k]
+	script := fixture.Script
+	if !ok {
+		script = s.unknown
+		if len(script) == 0 {
+			script = []Step{{Status: http.StatusNotFound}}
+		}
+	}
+
+	n := s.calls[k]
+	s.calls[k]++
+	if len(script) == 0 {
+		return fixture, ok, Step{}
+	}
+	return fixture, ok, script[min(n, len(script)-1)]
+}
+
+// GetInfo answers with the next step of the script of the song.
+func (s *Server) GetInfo(w http.ResponseWriter, r *http.Request, params api.GetInfoParams) {
+	fixture, listed, step := s.next(params.Group, params.Song)
+	s.logger.Info("GetInfo called with" + fmt.Sprintf(" %s %s, answering %+v", params.Group, params.Song, step))
+
+	if step.Latency > 0 {
+		select {
+		case <-time.After(time.Duration(step.Latency)):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	status := step.Status
+	if status == 0 {
+		status = http.StatusOK
+		if !listed {
+			status = http.StatusNotFound
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if status != http.StatusOK {
+		return
+	}
+
+	data, _ := json.Marshal(api.SongDetail{ReleaseDate: fixture.ReleaseDate, Text: fixture.Text, Link: fixture.Link})
+	if step.Malformed {
+		data = data[:len(data)/2]
+	}
+	w.Write(data)
+}
+
+// Reset reads the fixture file again and restarts every script.
+func (s *Server) Reset(w http.ResponseWriter, r *http.Request) {
+	if err := s.load(); err != nil {
+		s.logger.Error("Error reloading fixtures " + err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.logger.Info("Fixtures reloaded from " + s.path)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Handler routes the info service API and POST /reset to the server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /reset", s.Reset)
+	api.HandlerFromMux(s, mux)
+	return mux
+}
+
+func main() {
+	addr := flag.String("addr", defaultAddr(), "address to listen on")
+	fixtures := flag.String("fixtures", config.Dir(filepath.Join("Service", "musicservice", "cmd", "fakeinfo", "fixtures.json")), "fixture file to serve")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	srv, err := NewServer(logger, *fixtures)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fakeinfo:", err)
+		os.Exit(1)
+	}
+
+	logger.Info("Serving fixtures of " + *fixtures + " on " + *addr)
+	if err := http.ListenAndServe(*addr, srv.Handler()); err != nil {
+		fmt.Fprintln(os.Stderr, "fakeinfo:", err)
+		os.Exit(1)
+	}
+}
+
+// defaultAddr is the address of the info service configured in config.env,
+// falling back to the default port when there is no config.
+func defaultAddr() string {
+	_, confAPI, err := config.RetuneServerConfig()
+	if err != nil || confAPI.Server.Port == "" {
+		return "0.0.0.0:8070"
+	}
+	return confAPI.Server.Host + ":" + confAPI.Server.Port
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"client"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"musicservice/interal/app"
+	"musicservice/interal/models"
+	"musicservice/pkg/metadata"
+	"musicservice/pkg/sql/memory"
+	"musicservice/pkg/upstream"
+)
+
+// newFakeInfo serves the fixtures shipped with the command.
+func newFakeInfo(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	srv, err := NewServer(logger, "fixtures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// enrich creates the song in an App asking url for its details and waits
+// until enriching it is done or given up on.
+func enrich(t *testing.T, url, group, song string) (models.Song, models.Enrichment) {
+	t.Helper()
+
+	infoClient := upstream.NewClient(&http.Client{}, upstream.Config{
+		Timeout:    200 * time.Millisecond,
+		Retries:    2,
+		Backoff:    time.Millisecond,
+		MaxBackoff: time.Millisecond,
+	})
+	info, err := client.NewClientWithResponses(url, client.WithHTTPClient(infoClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := memory.NewMemory()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := app.NewApp(logger, db, []app.MetadataProvider{metadata.NewHTTP(info)}, 0, 0.3, 1)
+	id, err := a.CreateSong(models.NewSong{Group: group, Song: song, Language: "english"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.EnrichSongs(ctx, app.EnrichConfig{Workers: 1, PollInterval: time.Millisecond, Lease: time.Minute, MaxAttempts: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond})
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := db.Enrichment(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Status == models.EnrichmentDone || status.Status == models.EnrichmentFailed {
+			s, err := a.GetSong(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s, status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("enrichment still %s after 5s", status.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestScenarios(t *testing.T) {
+	tests := []struct {
+		name     string
+		group    string
+		song     string
+		status   string
+		attempts int
+		err      string
+		detail   client.SongDetail
+	}{
+		{"found", "Muse", "Supermassive Black Hole", models.EnrichmentDone, 1, "", client.SongDetail{ReleaseDate: "16.07.2006", Link: "https://www.youtube.com/watch?v=Xsp3_a-PMTw"}},
+		{"partial details", "Fixture", "Partial", models.EnrichmentDone, 1, "", client.SongDetail{}},
+		{"500 then found", "Fixture", "Flaky", models.EnrichmentDone, 1, "", client.SongDetail{ReleaseDate: "01.01.2020", Link: "https://example.com/flaky"}},
+		{"404", "Nobody", "Unknown", models.EnrichmentFailed, 1, "no song info", client.SongDetail{}},
+		{"500", "Fixture", "Down", models.EnrichmentFailed, 2, "status code 500", client.SongDetail{}},
+		{"latency", "Fixture", "Slow", models.EnrichmentFailed, 2, "deadline exceeded", client.SongDetail{}},
+		{"malformed", "Fixture", "Malformed", models.EnrichmentFailed, 2, "failed to get song info", client.SongDetail{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newFakeInfo(t)
+
+			song, status := enrich(t, ts.URL, tt.group, tt.song)
+			if status.Status != tt.status || status.Attempts != tt.attempts {
+				t.Fatalf("got %+v, want %s after %d attempts", status, tt.status, tt.attempts)
+			}
+			if !strings.Contains(status.LastError, tt.err) {
+				t.Errorf("last error = %q, want it to mention %q", status.LastError, tt.err)
+			}
+			if song.ReleaseDate != tt.detail.ReleaseDate || song.Link != tt.detail.Link {
+				t.Errorf("got %q %q, want %q %q", song.ReleaseDate, song.Link, tt.detail.ReleaseDate, tt.detail.Link)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	ts := newFakeInfo(t)
+
+	get := func() int {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/info?group=Fixture&song=Flaky")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, want := range []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK, http.StatusOK} {
+		if got := get(); got != want {
+			t.Fatalf("status = %d, want %d", got, want)
+		}
+	}
+
+	resp, err := http.Post(ts.URL+"/reset", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("reset status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	if got := get(); got != http.StatusInternalServerError {
+		t.Errorf("status after reset = %d, want the script restarted", got)
+	}
+}
@@ -3,19 +3,25 @@ package main
 import (
 
 	"client"
-	"client/server"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"musicservice/cmd/migration"
 	"musicservice/interal/app"
 	"musicservice/interal/server"
 	"musicservice/pkg/config"
+	"musicservice/pkg/infocache"
+	"musicservice/pkg/metadata"
 	"musicservice/pkg/sql/postgres"
+	"musicservice/pkg/sql/sqlite"
+	"musicservice/pkg/upstream"
 
     _"github.com/swaggo/http-swagger"
 )
@@ -26,44 +32,6 @@ const (
     envProd  = "prod"
 )
 
-type Server struct{}
-
-func NewServer() Server {
- return Server{}
-}
-
-
-func (Server) GetInfo(w http.ResponseWriter, r *http.Request, param api.GetInfoParams) {
-    w.Header().Set("Content-type", "application/json")
-    
-    data := &api.SongDetail{
-        Link: "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
-        ReleaseDate: "16.07.2006",
-        Text: "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight",
-    }
-    
-    json.NewEncoder(w).Encode(data)
-}
-
-func initializing() {
-    migration.Migrations()
-    
-    app := NewServer()
-
-    log.Println("Initializing server...")
-    e := http.NewServeMux()
-    h := api.HandlerFromMux(app, e)
-    
-    s := &http.Server{
-        Addr:    "0.0.0.0:8070",
-        Handler: h,
-    }
-    
-    if err := s.ListenAndServe(); err != nil {
-        log.Fatal(err)
-    }
-
-}
 // @title           Swagger Example API
 // @version         2.0
 // @description     This is a sample server celler server.
@@ -71,26 +39,49 @@ func initializing() {
 // @host      localhost:8080
 // @BasePath  /
 func main() {
-    go initializing()
-
 	loger := setupLogger("local")
 	loger = loger.With(slog.String("env", "local"))
 
 	loger.Info("initializing server") 
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
     
-    confPost, err := config.ReturnedDatabase()
+    confStorage, err := config.ReturnedStorage()
     if err!= nil {
         loger.Error("error initializing config", slog.String("error", err.Error()))
         panic(err)
     }
 
-    loger.Info("connecting to database " + confPost.DBName)
-    postgres, err := postgres.NewPostgres(confPost.User, confPost.Password, confPost.DBName, confPost.Host, confPost.Port)
-    if err != nil {
-       loger.Error("error initializing postgres", slog.String("error", err.Error()))
-       panic(err)
+    var store app.SongStore
+    switch confStorage.Driver {
+    case config.DriverSQLite:
+        loger.Info("opening sqlite database " + confStorage.SQLitePath)
+        sqlite, err := sqlite.NewSQLite(confStorage.SQLitePath)
+        if err != nil {
+            loger.Error("error initializing sqlite", slog.String("error", err.Error()))
+            panic(err)
+        }
+        store = sqlite
+    default:
+        confPost, err := config.ReturnedDatabase()
+        if err!= nil {
+            loger.Error("error initializing config", slog.String("error", err.Error()))
+            panic(err)
+        }
+
+        loger.Info("connecting to database " + confPost.DBName)
+        postgres, err := postgres.NewPostgres(confPost.User, confPost.Password, confPost.DBName, confPost.Host, confPost.Port)
+        if err != nil {
+           loger.Error("error initializing postgres", slog.String("error", err.Error()))
+           panic(err)
+        }
+        store = postgres
     }
 
+    loger.Info("applying migrations")
+    migration.Migrations()
+
     loger.Info("initializing server config")
     confServer, confAPI, err := config.RetuneServerConfig()
     if err!= nil {
@@ -98,35 +89,159 @@ func main() {
         panic(err)
     }
 
+    loger.Info("initializing upstream config")
+    confUpstream, err := config.ReturnedUpstream()
+    if err != nil {
+        loger.Error("error initializing config", slog.String("error", err.Error()))
+        panic(err)
+    }
+
     loger.Info("initializing client config")
-    clientMusic, err := client.NewClientWithResponses("http://" + confAPI.Server.Host + ":" + confAPI.Server.Port, client.WithHTTPClient(&http.Client{}))
+    infoClient := upstream.NewClient(&http.Client{}, upstream.Config{
+        Timeout:         confUpstream.Timeout,
+        Retries:         confUpstream.Retries,
+        Backoff:         confUpstream.Backoff,
+        MaxBackoff:      confUpstream.MaxBackoff,
+        BreakerFailures: confUpstream.BreakerFailures,
+        BreakerCooldown: confUpstream.BreakerCooldown,
+    })
+    infoClient.Breaker().OnChange(func(from, to string) {
+        loger.Warn("upstream circuit breaker " + from + " -> " + to)
+    })
+    clientMusic, err := client.NewClientWithResponses("http://" + confAPI.Server.Host + ":" + confAPI.Server.Port, client.WithHTTPClient(infoClient))
     if err != nil {
         loger.Error("error initializing client", slog.String("error", err.Error()))
         panic(err)
     }
 
+    loger.Info("initializing info cache config")
+    confInfoCache, err := config.ReturnedInfoCache()
+    if err != nil {
+        loger.Error("error initializing config", slog.String("error", err.Error()))
+        panic(err)
+    }
+
+    var info metadata.InfoClient = clientMusic
+    if confInfoCache.Size > 0 {
+        info = infocache.New(clientMusic, infocache.Config{
+            Size:        confInfoCache.Size,
+            TTL:         confInfoCache.TTL,
+            NegativeTTL: confInfoCache.NegativeTTL,
+        })
+    }
+
+    loger.Info("initializing metadata config")
+    confMetadata, err := config.ReturnedMetadata()
+    if err != nil {
+        loger.Error("error initializing config", slog.String("error", err.Error()))
+        panic(err)
+    }
+
+    var providers []app.MetadataProvider
+    for _, name := range confMetadata.Providers {
+        switch name {
+        case config.ProviderHTTP:
+            providers = append(providers, metadata.NewHTTP(info))
+        case config.ProviderFiles:
+            loger.Info("reading song details from " + confMetadata.FilesPath)
+            files, err := metadata.NewFiles(confMetadata.FilesPath)
+            if err != nil {
+                loger.Error("error initializing files provider", slog.String("error", err.Error()))
+                panic(err)
+            }
+            providers = append(providers, files)
+        }
+    }
+
+    loger.Info("initializing trash config")
+    confTrash, err := config.ReturnedTrash()
+    if err != nil {
+        loger.Error("error initializing config", slog.String("error", err.Error()))
+        panic(err)
+    }
+
+    loger.Info("initializing search config")
+    confSearch, err := config.ReturnedSearch()
+    if err != nil {
+        loger.Error("error initializing config", slog.String("error", err.Error()))
+        panic(err)
+    }
+
+    loger.Info("initializing import config")
+    confImport, err := config.ReturnedImport()
+    if err != nil {
+        loger.Error("error initializing config", slog.String("error", err.Error()))
+        panic(err)
+    }
+
+    loger.Info("initializing enrichment config")
+    confEnrich, err := config.ReturnedEnrich()
+    if err != nil {
+        loger.Error("error initializing config", slog.String("error", err.Error()))
+        panic(err)
+    }
+
+    loger.Info("initializing resync config")
+    confResync, err := config.ReturnedResync()
+    if err != nil {
+        loger.Error("error initializing config", slog.String("error", err.Error()))
+        panic(err)
+    }
+
     loger.Info("initializing server app")  
-    app := app.NewApp(loger, postgres, clientMusic)
+    enrich := app.EnrichConfig{
+        Workers:      confEnrich.Workers,
+        PollInterval: confEnrich.PollInterval,
+        Lease:        confEnrich.Lease,
+        MaxAttempts:  confEnrich.MaxAttempts,
+        Backoff:      confEnrich.Backoff,
+        MaxBackoff:   confEnrich.MaxBackoff,
+    }
+    app := app.NewApp(loger, store, providers, confTrash.Retention, confSearch.FuzzyThreshold, confImport.Workers)
+    purging := make(chan struct{})
+    go func() {
+        if confTrash.PurgeInterval > 0 {
+            app.PurgeTrashEvery(ctx, confTrash.PurgeInterval)
+        }
+        close(purging)
+    }()
+    enriching := make(chan struct{})
+    go func() {
+        app.EnrichSongs(ctx, enrich)
+        close(enriching)
+    }()
+    if confResync.Interval > 0 {
+        go app.ResyncEvery(ctx, confResync.Interval, confResync.Batch, confResync.Apply)
+    }
     server := server.NewMysicServer(loger, *app)
 
     loger.Info("Initializing server endpoints")
     
-    http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+    mux := server.Routes()
+    mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
         loger.Info("Received request: " + r.URL.String())
         fmt.Fprint(w, "Server listening on " + r.URL.Host)
     })
-    http.HandleFunc("/search", server.GetData)
-    http.HandleFunc("/text", server.GetText)
-    http.HandleFunc("/delete", server.DeleteSong)
-    http.HandleFunc("/update", server.UpdateSong)
-    http.HandleFunc("/create", server.CreateSong)
     
+    srv := &http.Server{Addr: confServer.Host + ":" + confServer.Port, Handler: mux}
+    go func() {
+        <-ctx.Done()
+        loger.Info("Shutting down server...")
+        shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+        srv.Shutdown(shutdown)
+    }()
+
     loger.Info("Starting server..." + confServer.Host + " " + confServer.Port)
-    err = http.ListenAndServe(confServer.Host + ":" + confServer.Port, nil) 
-    if err != nil {
+    err = srv.ListenAndServe()
+    if err != nil && !errors.Is(err, http.ErrServerClosed) {
         loger.Error("error starting server", slog.String("error",err.Error()))
         panic(err)
     }
+
+    <-enriching
+    <-purging
+    loger.Info("Server stopped")
 }
 
 func setupLogger(env string) *slog.Logger {
@@ -145,4 +260,3 @@ func setupLogger(env string) *slog.Logger {
 
     return logger
 }
-
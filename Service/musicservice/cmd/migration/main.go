@@ -7,6 +7,7 @@ import (
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
@@ -17,10 +18,21 @@ func Migrations() {
         panic(err)
     }
 
-	m, err := migrate.New(
-		"file://"+ config.Dir(confMigrat.MigrationsPath), 
-		fmt.Sprintf("postgres://%s:%s@%s:%s/%s?x-migrations-table=%s&sslmode=disable", confPostgres.User, confPostgres.Password, confPostgres.Host, confPostgres.Port, confPostgres.DBName, confMigrat.MigrationsTable),
-	)
+	confStorage, err := config.ReturnedStorage()
+	if err!= nil {
+		fmt.Println("Error initial config storage")
+		panic(err)
+	}
+
+	source := "file://"+ config.Dir(confMigrat.MigrationsPath)
+	database := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?x-migrations-table=%s&sslmode=disable", confPostgres.User, confPostgres.Password, confPostgres.Host, confPostgres.Port, confPostgres.DBName, confMigrat.MigrationsTable)
+
+	if confStorage.Driver == config.DriverSQLite {
+		source = "file://"+ config.Dir(confMigrat.SQLiteMigrationsPath)
+		database = fmt.Sprintf("sqlite://%s?x-migrations-table=%s", confStorage.SQLitePath, confMigrat.MigrationsTable)
+	}
+
+	m, err := migrate.New(source, database)
 	 
 	if err != nil {
 		panic(err)
@@ -36,4 +48,3 @@ func Migrations() {
 
 	fmt.Println("Migrations applied to the database successfully")
 }
-
@@ -0,0 +1,106 @@
+// Command songimport uploads a CSV or NDJSON file of songs to the bulk
+// import endpoint of a running music service and prints its report.
+//
+//	songimport [-url http://localhost:8080] [-format csv|ndjson] file
+//
+// The file "-" is read from standard input. The exit status is 1 when any
+// row failed to import.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/config"
+)
+
+func main() {
+	serverURL := flag.String("url", defaultURL(), "base URL of the music service")
+	format := flag.String("format", "", "format of the file, csv or ndjson; taken from the file extension when empty")
+	timeout := flag.Duration("timeout", 10*time.Minute, "how long to wait for the import to finish")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: songimport [-url URL] [-format csv|ndjson] file")
+		os.Exit(2)
+	}
+
+	report, err := upload(*serverURL, flag.Arg(0), *format, *timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "songimport:", err)
+		os.Exit(1)
+	}
+
+	for _, row := range report.Rows {
+		if row.Status == models.ImportFailed {
+			fmt.Printf("row %d: %s / %s failed: %s\n", row.Row, row.Group, row.Song, row.Error)
+		}
+	}
+	fmt.Printf("%d created, %d duplicates, %d failed\n", report.Created, report.Duplicates, report.Failed)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// defaultURL points at the service configured in config.env, falling back
+// to the default port when there is no config.
+func defaultURL() string {
+	confServer, _, err := config.RetuneServerConfig()
+	if err != nil || confServer.Port == "" {
+		return "http://localhost:8080"
+	}
+	return "http://localhost:" + confServer.Port
+}
+
+func upload(serverURL, path, format string, timeout time.Duration) (models.ImportReport, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	if format != "csv" && format != "ndjson" {
+		return models.ImportReport{}, fmt.Errorf("unknown format %q, set -format to csv or ndjson", format)
+	}
+
+	var body io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return models.ImportReport{}, err
+		}
+		defer f.Close()
+		body = f
+	}
+
+	endpoint, err := url.JoinPath(serverURL, "songs", "import")
+	if err != nil {
+		return models.ImportReport{}, fmt.Errorf("invalid url: %w", err)
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Post(endpoint+"?format="+format, "application/octet-stream", body)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var problem struct {
+			Detail string `json:"detail"`
+		}
+		json.NewDecoder(resp.Body).Decode(&problem)
+		return models.ImportReport{}, fmt.Errorf("import failed with status %d: %s", resp.StatusCode, problem.Detail)
+	}
+
+	var report models.ImportReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return models.ImportReport{}, fmt.Errorf("failed to read report: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"musicservice/interal/models"
+)
+
+// ErrInvalidCursor is returned for a cursor this service did not issue.
+var ErrInvalidCursor = models.Invalid("invalid_cursor", "invalid cursor")
+
+// Cursors are opaque to clients. Pages ordered by id continue after the
+// last id seen; pages ordered by search rank have no stable key and
+// continue at an offset.
+const (
+	cursorID     = "id"
+	cursorOffset = "offset"
+)
+
+func encodeCursor(kind string, n uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(kind + ":" + strconv.FormatUint(n, 10)))
+}
+
+// applyCursor decodes page.Cursor into the position the page starts at.
+func applyCursor(page *models.Page) error {
+	if page.Cursor == "" {
+		return nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	kind, value, _ := strings.Cut(string(raw), ":")
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	switch kind {
+	case cursorID:
+		page.After = n
+	case cursorOffset:
+		page.Offset = int(n)
+	default:
+		return ErrInvalidCursor
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/lang"
+)
+
+// EnrichConfig sets how songs are enriched in the background. Workers jobs
+// run at a time, each claimed for Lease; the queue is polled every
+// PollInterval while it is empty. A failed job is tried MaxAttempts times,
+// waiting Backoff before the first retry and twice as long before each one
+// after it, but never longer than MaxBackoff.
+type EnrichConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	Lease        time.Duration
+	MaxAttempts  int
+	Backoff      time.Duration
+	MaxBackoff   time.Duration
+}
+
+// retryDelay returns how long to wait before trying a job again after
+// attempt failed.
+func (conf EnrichConfig) retryDelay(attempt int) time.Duration {
+	d := conf.Backoff
+	for range attempt - 1 {
+		if d >= conf.MaxBackoff || d > math.MaxInt64/2 {
+			break
+		}
+		d *= 2
+	}
+	return min(d, conf.MaxBackoff)
+}
+
+// Enrichment returns how far fetching the details of a song has got.
+func (a *App) Enrichment(id uint64) (models.Enrichment, error) {
+	log := a.logger.With(
+		slog.String("OP", "Enrichment"),
+	)
+	log.Info("Enrichment called with song" + fmt.Sprintf(" %d", id))
+
+	status, err := a.db.Enrichment(id)
+	if err != nil {
+		log.Debug("Error getting enrichment" + fmt.Sprintf(" %d", id))
+		return models.Enrichment{}, fmt.Errorf("failed to get enrichment: %w", err)
+	}
+	return status, nil
+}
+
+// RetryEnrichment queues a song whose enrichment failed once more.
+func (a *App) RetryEnrichment(id uint64) error {
+	log := a.logger.With(
+		slog.String("OP", "RetryEnrichment"),
+	)
+	log.Info("RetryEnrichment called with song" + fmt.Sprintf(" %d", id))
+
+	err := a.db.RetryEnrichment(id)
+	if err != nil {
+		log.Debug("Error retrying enrichment" + fmt.Sprintf(" %d", id))
+		return fmt.Errorf("failed to retry enrichment: %w", err)
+	}
+
+	log.Info("Enrichment queued again" + fmt.Sprintf(" %d", id))
+	return nil
+}
+
+// EnrichSongs works through the enrichment queue with a pool of workers,
+// claiming a job whenever a worker is free. It returns once ctx is done and
+// the jobs being worked on have stopped.
+func (a *App) EnrichSongs(ctx context.Context, conf EnrichConfig) {
+	log := a.logger.With(
+		slog.String("OP", "EnrichSongs"),
+	)
+
+	free := make(chan struct{}, max(conf.Workers, 1))
+	for ctx.Err() == nil {
+		select {
+		case free <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+
+		jobs, err := a.db.ClaimEnrichments(1, conf.Lease)
+		if err != nil {
+			log.Error("Error claiming enrichment " + err.Error())
+		}
+		if len(jobs) == 0 {
+			<-free
+			select {
+			case <-time.After(conf.PollInterval):
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		go func() {
+			defer func() { <-free }()
+			a.enrich(ctx, jobs[0], conf)
+		}()
+	}
+
+	for range cap(free) {
+		free <- struct{}{}
+	}
+	log.Info("Enrichment stopped")
+}
+
+// enrich fetches the details of one song and saves them, or records why it
+// could not. A song the info service does not know is given up on at once;
+// other failures are retried with backoff until the attempts run out. A job
+// stopped by ctx is left to run again once its lease is over.
+func (a *App) enrich(ctx context.Context, job models.EnrichmentJob, conf EnrichConfig) {
+	log := a.logger.With(
+		slog.String("OP", "enrich"),
+	)
+	log.Info("Enriching song" + fmt.Sprintf(" %d %s %s attempt %d", job.SongID, job.Group, job.Song, job.Attempt))
+
+	err := a.fetchAndSave(ctx, job)
+	if err == nil {
+		log.Info("Song enriched" + fmt.Sprintf(" %d", job.SongID))
+		return
+	}
+	if ctx.Err() != nil {
+		log.Warn("Enriching song stopped" + fmt.Sprintf(" %d: %v", job.SongID, err))
+		return
+	}
+
+	var retryAt time.Time
+	if !errors.Is(err, models.ErrNotFound) && job.Attempt < conf.MaxAttempts {
+		retryAt = time.Now().Add(conf.retryDelay(job.Attempt))
+		log.Warn("Error enriching song" + fmt.Sprintf(" %d attempt %d: %v, retry at %v", job.SongID, job.Attempt, err, retryAt))
+	} else {
+		log.Error("Error enriching song" + fmt.Sprintf(" %d attempt %d: %v, giving up", job.SongID, job.Attempt, err))
+	}
+
+	if err := a.db.FailEnrichment(job.SongID, err.Error(), retryAt); err != nil {
+		log.Error("Error saving enrichment failure" + fmt.Sprintf(" %d: %v", job.SongID, err))
+	}
+}
+
+func (a *App) fetchAndSave(ctx context.Context, job models.EnrichmentJob) error {
+	data, err := a.fetchInfo(ctx, job.Group, job.Song)
+	if err != nil {
+		return err
+	}
+
+	language := ""
+	if job.DetectLanguage {
+		language = lang.Detect(data.Text)
+	}
+
+	err = a.db.CompleteEnrichment(job.SongID, data, language)
+	if err != nil {
+		return fmt.Errorf("failed to save song info: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"client"
+	"context"
+	"testing"
+	"time"
+
+	"musicservice/interal/models"
+)
+
+// stubProvider answers every song with detail, or with err when it is set.
+type stubProvider struct {
+	name   string
+	detail client.SongDetail
+	err    error
+}
+
+func (p stubProvider) Name() string { return p.name }
+
+func (p stubProvider) SongInfo(ctx context.Context, group, song string) (client.SongDetail, error) {
+	return p.detail, p.err
+}
+
+func TestRetryDelay(t *testing.T) {
+	conf := EnrichConfig{Backoff: 30 * time.Second, MaxBackoff: time.Hour}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{8, time.Hour},
+		{64, time.Hour},
+		{1000, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := conf.retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	conf := EnrichConfig{Lease: time.Minute, MaxAttempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour}
+
+	tests := []struct {
+		name     string
+		provider stubProvider
+		attempt  int
+		status   string
+		retry    bool
+	}{
+		{"found", stubProvider{detail: client.SongDetail{ReleaseDate: "16.07.2006", Text: "Ooh baby", Link: "https://example.com"}}, 1, models.EnrichmentDone, false},
+		{"not found gives up at once", stubProvider{err: models.NotFound("song_info_not_found", "no song info")}, 1, models.EnrichmentFailed, false},
+		{"upstream failure is retried", stubProvider{err: models.Upstream("upstream_failed", "boom")}, 1, models.EnrichmentPending, true},
+		{"last attempt gives up", stubProvider{err: models.Upstream("upstream_failed", "boom")}, 3, models.EnrichmentFailed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, db := newApp(t)
+			a.providers = []MetadataProvider{tt.provider}
+			id, err := a.CreateSong(models.NewSong{Group: "Muse", Song: "Hysteria"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			jobs, err := db.ClaimEnrichments(1, conf.Lease)
+			if err != nil || len(jobs) != 1 {
+				t.Fatalf("claimed %d jobs: %v", len(jobs), err)
+			}
+			job := jobs[0]
+			job.Attempt = tt.attempt
+			a.enrich(context.Background(), job, conf)
+
+			status, err := db.Enrichment(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.Status != tt.status {
+				t.Fatalf("status = %s, want %s", status.Status, tt.status)
+			}
+			if tt.retry && (status.NextAttemptAt == nil || time.Until(*status.NextAttemptAt) < 59*time.Minute) {
+				t.Errorf("next attempt at %v, want in an hour", status.NextAttemptAt)
+			}
+			if jobs, _ := db.ClaimEnrichments(1, conf.Lease); len(jobs) != 0 {
+				t.Errorf("job was claimed again at once")
+			}
+		})
+	}
+}
+
+// blockingProvider answers once ctx is done, signalling started when asked.
+type blockingProvider struct {
+	started chan struct{}
+}
+
+func (p blockingProvider) Name() string { return "blocking" }
+
+func (p blockingProvider) SongInfo(ctx context.Context, group, song string) (client.SongDetail, error) {
+	close(p.started)
+	<-ctx.Done()
+	return client.SongDetail{}, models.Upstream("upstream_failed", "failed to get song info: %v", ctx.Err())
+}
+
+func TestEnrichSongsStops(t *testing.T) {
+	a, db := newApp(t)
+	provider := blockingProvider{started: make(chan struct{})}
+	a.providers = []MetadataProvider{provider}
+	id, err := a.CreateSong(models.NewSong{Group: "Muse", Song: "Hysteria"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.EnrichSongs(ctx, EnrichConfig{Workers: 2, PollInterval: time.Millisecond, Lease: time.Minute, MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Second})
+		close(done)
+	}()
+
+	<-provider.started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("EnrichSongs did not return")
+	}
+
+	status, err := db.Enrichment(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != models.EnrichmentRunning || status.LastError != "" {
+		t.Errorf("got %+v, want the job left running for its lease", status)
+	}
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"musicservice/interal/models"
+)
+
+// ExportSongs calls fn with every song matching the filter in id order,
+// streaming them from the database instead of loading the catalog into
+// memory. Lyrics are left out unless withText is set. An error from fn stops
+// the export and is returned as is.
+func (a *App) ExportSongs(filter models.FilterSong, withText bool, fn func(models.Song) error) error {
+	log := a.logger.With(
+		slog.String("OP", "ExportSongs"),
+	)
+	log.Info("ExportSongs called with filter" + fmt.Sprintf(" %v", filter))
+
+	filtermap, err := a.filterMap(filter)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	err = a.db.ExportSongs(filtermap, withText, func(song models.Song) error {
+		count++
+		return fn(song)
+	})
+	if err != nil {
+		log.Error("Error exporting songs" + fmt.Sprintf(" after %d: %v", count, err))
+		return fmt.Errorf("failed to export songs: %w", err)
+	}
+
+	log.Info("ExportSongs complete with" + fmt.Sprintf(" %d songs", count))
+	return nil
+}
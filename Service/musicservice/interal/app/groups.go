@@ -0,0 +1,134 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"musicservice/interal/models"
+)
+
+// ErrInvalidGroup is returned for a group name a group cannot have.
+var ErrInvalidGroup = models.Invalid("invalid_group", "invalid group")
+
+// Groups returns one page of the groups with the number of their songs.
+func (a *App) Groups(page models.Page) (models.GroupsPage, error) {
+	log := a.logger.With(
+		slog.String("OP", "Groups"),
+	)
+	log.Info("Groups called with page" + fmt.Sprintf(" %v", page))
+
+	page.Limit = pageLimit(page.Limit)
+
+	groups, total, err := a.db.Groups(page)
+	if err != nil {
+		log.Error("Error getting groups " + err.Error())
+		return models.GroupsPage{}, fmt.Errorf("failed to get groups: %w", err)
+	}
+
+	log.Info("Groups complete with" + fmt.Sprintf(" %d groups", len(groups)))
+	return models.GroupsPage{Groups: groups, Total: total}, nil
+}
+
+// GetGroup returns a group with one page of its songs, in id order.
+func (a *App) GetGroup(name string, page models.Page) (models.GroupPage, error) {
+	log := a.logger.With(
+		slog.String("OP", "GetGroup"),
+	)
+	log.Info("GetGroup called with group" + fmt.Sprintf(" %q", name))
+
+	group, err := a.db.Group(name)
+	if err != nil {
+		log.Debug("Error getting group" + fmt.Sprintf(" %q", name))
+		return models.GroupPage{}, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	if err := applyCursor(&page); err != nil {
+		return models.GroupPage{}, err
+	}
+	limit := pageLimit(page.Limit)
+	page.Limit = limit + 1
+
+	songs, _, err := a.db.GetSongs(map[string]string{"group": name}, page)
+	if err != nil {
+		log.Error("Error getting songs of group" + fmt.Sprintf(" %q", name))
+		return models.GroupPage{}, fmt.Errorf("failed to get songs: %w", err)
+	}
+
+	result := models.GroupPage{Group: group, Songs: songs}
+	if len(songs) > limit {
+		result.Songs = songs[:limit]
+		id, _ := strconv.ParseUint(result.Songs[limit-1].ID, 10, 64)
+		result.NextCursor = encodeCursor(cursorID, id)
+	}
+
+	log.Info("GetGroup complete with" + fmt.Sprintf(" %d songs", len(result.Songs)))
+	return result, nil
+}
+
+// RenameGroup renames a group and every song of it. The new name must not
+// be taken; spelling variants of one group are merged with MergeGroups.
+func (a *App) RenameGroup(name, newName string) error {
+	log := a.logger.With(
+		slog.String("OP", "RenameGroup"),
+	)
+	log.Info("RenameGroup called with group" + fmt.Sprintf(" %q to %q", name, newName))
+
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("%w: new group name is required", ErrInvalidGroup)
+	}
+	if newName == name {
+		return nil
+	}
+
+	err := a.db.RenameGroup(name, newName)
+	if err != nil {
+		log.Debug("Error renaming group" + fmt.Sprintf(" %q", name))
+		return fmt.Errorf("failed to rename group: %w", err)
+	}
+
+	log.Info("Group renamed" + fmt.Sprintf(" %q to %q", name, newName))
+	return nil
+}
+
+// MergeGroups moves the songs of group name into group into and removes
+// name. It fails when both groups have a song with the same title.
+func (a *App) MergeGroups(name, into string) error {
+	log := a.logger.With(
+		slog.String("OP", "MergeGroups"),
+	)
+	log.Info("MergeGroups called with group" + fmt.Sprintf(" %q into %q", name, into))
+
+	if into == "" {
+		return fmt.Errorf("%w: group to merge into is required", ErrInvalidGroup)
+	}
+
+	err := a.db.MergeGroups(name, into)
+	if err != nil {
+		log.Debug("Error merging group" + fmt.Sprintf(" %q", name))
+		return fmt.Errorf("failed to merge groups: %w", err)
+	}
+
+	log.Info("Groups merged" + fmt.Sprintf(" %q into %q", name, into))
+	return nil
+}
+
+// DeleteGroup removes a group without songs. Songs in the trash still
+// belong to their group, so it has to be purged of them first.
+func (a *App) DeleteGroup(name string) error {
+	log := a.logger.With(
+		slog.String("OP", "DeleteGroup"),
+	)
+	log.Info("DeleteGroup called with group" + fmt.Sprintf(" %q", name))
+
+	err := a.db.DeleteGroup(name)
+	if err != nil {
+		log.Debug("Error deleting group" + fmt.Sprintf(" %q", name))
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	log.Info("Group deleted" + fmt.Sprintf(" %q", name))
+	return nil
+}
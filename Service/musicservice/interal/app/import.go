@@ -0,0 +1,240 @@
+package app
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"musicservice/interal/models"
+)
+
+// Formats a bulk import can be read from.
+const (
+	ImportCSV    = "csv"
+	ImportNDJSON = "ndjson"
+)
+
+// MaxImportRows is the most rows one bulk import may have.
+const MaxImportRows = 10000
+
+// ErrInvalidImport is returned for an import file that cannot be read.
+var ErrInvalidImport = models.Invalid("invalid_import", "invalid import")
+
+// ImportEntry is one row of an import file: the song it asks for, or the
+// reason the row could not be read.
+type ImportEntry struct {
+	Row  int
+	Song models.NewSong
+	Err  error
+}
+
+// ParseImport reads the rows of an import file. CSV files have the columns
+// group, song and an optional language, in that order unless a header row
+// names them; NDJSON files have one NewSong object per line. A row that
+// cannot be read becomes an entry with an error rather than failing the
+// whole file.
+func ParseImport(r io.Reader, format string) ([]ImportEntry, error) {
+	var entries []ImportEntry
+	var err error
+	switch format {
+	case ImportCSV:
+		entries, err = parseCSV(r)
+	case ImportNDJSON:
+		entries, err = parseNDJSON(r)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidImport, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) > MaxImportRows {
+		return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidImport, MaxImportRows)
+	}
+	for i, e := range entries {
+		if e.Err == nil && (e.Song.Group == "" || e.Song.Song == "") {
+			entries[i].Err = models.Invalid("song_key_required", "group and song are required")
+		}
+	}
+	return entries, nil
+}
+
+func parseCSV(r io.Reader) ([]ImportEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	columns := map[string]int{"group": 0, "song": 1, "language": 2}
+	var entries []ImportEntry
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			entries = append(entries, ImportEntry{Row: row, Err: fmt.Errorf("%w: %v", ErrInvalidImport, parseErr.Err)})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		if row == 1 && isHeader(record) {
+			columns = make(map[string]int, len(record))
+			for i, name := range record {
+				columns[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+			continue
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		entries = append(entries, ImportEntry{Row: row, Song: models.NewSong{
+			Group:    field("group"),
+			Song:     field("song"),
+			Language: field("language"),
+		}})
+	}
+}
+
+// isHeader reports whether a CSV record names the columns instead of
+// holding a song.
+func isHeader(record []string) bool {
+	for _, name := range record {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "group", "song":
+			return true
+		}
+	}
+	return false
+}
+
+func parseNDJSON(r io.Reader) ([]ImportEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var entries []ImportEntry
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var song models.NewSong
+		if err := json.Unmarshal([]byte(line), &song); err != nil {
+			entries = append(entries, ImportEntry{Row: row, Err: fmt.Errorf("%w: %v", ErrInvalidImport, err)})
+			continue
+		}
+		song.Group, song.Song = strings.TrimSpace(song.Group), strings.TrimSpace(song.Song)
+		entries = append(entries, ImportEntry{Row: row, Song: song})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+	return entries, nil
+}
+
+// ImportSongs creates the songs of an import with a pool of importWorkers,
+// queueing each for enrichment. Songs that already exist, or that an
+// earlier row of the same import asks for, are reported as duplicates.
+func (a *App) ImportSongs(entries []ImportEntry) models.ImportReport {
+	log := a.logger.With(
+		slog.String("OP", "ImportSongs"),
+	)
+	log.Info("ImportSongs called with" + fmt.Sprintf(" %d rows", len(entries)))
+
+	rows := make([]models.ImportRow, len(entries))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(a.importWorkers, len(entries)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rows[i] = a.importSong(entries[i])
+			}
+		}()
+	}
+
+	seen := make(map[[2]string]int, len(entries))
+	for i, e := range entries {
+		row := models.ImportRow{Row: e.Row, Group: e.Song.Group, Song: e.Song.Song}
+		key := [2]string{e.Song.Group, e.Song.Song}
+		switch first, dup := seen[key]; {
+		case e.Err != nil:
+			rows[i] = failedRow(row, e.Err)
+		case dup:
+			row.Status = models.ImportDuplicate
+			row.Error = fmt.Sprintf("same song as row %d", first)
+			rows[i] = row
+		default:
+			seen[key] = e.Row
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := models.ImportReport{Rows: rows}
+	for _, row := range rows {
+		switch row.Status {
+		case models.ImportCreated:
+			report.Created++
+		case models.ImportDuplicate:
+			report.Duplicates++
+		default:
+			report.Failed++
+		}
+	}
+
+	log.Info("ImportSongs complete with" + fmt.Sprintf(" %d created, %d duplicates, %d failed", report.Created, report.Duplicates, report.Failed))
+	return report
+}
+
+func (a *App) importSong(e ImportEntry) models.ImportRow {
+	row := models.ImportRow{Row: e.Row, Group: e.Song.Group, Song: e.Song.Song}
+
+	ids, err := a.db.SongIDs(e.Song.Group, e.Song.Song)
+	if err != nil {
+		return failedRow(row, fmt.Errorf("failed to look up song: %w", err))
+	}
+	if len(ids) > 0 {
+		row.Status, row.ID = models.ImportDuplicate, ids[0]
+		return row
+	}
+
+	id, err := a.CreateSong(e.Song)
+	if errors.Is(err, models.ErrSongExists) {
+		row.Status = models.ImportDuplicate
+		return row
+	}
+	if err != nil {
+		return failedRow(row, err)
+	}
+
+	row.Status, row.ID = models.ImportCreated, id
+	return row
+}
+
+// failedRow reports why a row failed, with the code of a domain error.
+func failedRow(row models.ImportRow, err error) models.ImportRow {
+	row.Status = models.ImportFailed
+	row.Error = err.Error()
+	var domain *models.Error
+	if errors.As(err, &domain) {
+		row.Code = domain.Code
+	}
+	return row
+}
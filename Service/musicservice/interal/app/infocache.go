@@ -0,0 +1,29 @@
+package app
+
+import (
+	"log/slog"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/infocache"
+)
+
+// ErrInfoCacheDisabled is returned for the stats of the info cache when
+// calls to the info service are not cached.
+var ErrInfoCacheDisabled = models.NotFound("info_cache_disabled", "song info cache is disabled")
+
+// InfoCacheStats returns the counters of the cache in front of the info
+// service.
+func (a *App) InfoCacheStats() (infocache.Stats, error) {
+	log := a.logger.With(
+		slog.String("OP", "InfoCacheStats"),
+	)
+	log.Info("InfoCacheStats called")
+
+	for _, p := range a.providers {
+		cached, ok := p.(interface{ Cache() *infocache.Cache })
+		if ok && cached.Cache() != nil {
+			return cached.Cache().Stats(), nil
+		}
+	}
+	return infocache.Stats{}, ErrInfoCacheDisabled
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/fuzzy"
+)
+
+// ErrInvalidPage is returned for a page of lyrics that cannot exist.
+var ErrInvalidPage = models.Invalid("invalid_page", "invalid page")
+
+// splitVerses breaks lyrics into verses at blank lines. Trailing spaces and
+// Windows line endings are dropped, and runs of blank lines count as one.
+func splitVerses(text string) []models.Verse {
+	var verses []models.Verse
+	var lines []string
+	flush := func() {
+		if len(lines) > 0 {
+			verses = append(verses, models.Verse{Index: len(verses) + 1, Lines: lines})
+			lines = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			flush()
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return verses
+}
+
+// versePage cuts page, counting from 1, of size verses out of verses. A page
+// past the end has no verses.
+func versePage(verses []models.Verse, page, size int) models.Lyrics {
+	start := len(verses)
+	if page-1 <= len(verses)/size {
+		start = min((page-1)*size, len(verses))
+	}
+	end := min(start+size, len(verses))
+	return models.Lyrics{
+		Verses:      append([]models.Verse{}, verses[start:end]...),
+		Page:        page,
+		PageSize:    size,
+		TotalVerses: len(verses),
+		HasNext:     end < len(verses),
+	}
+}
+
+// SearchLyrics finds the lines of a song's lyrics that contain a phrase,
+// ignoring case, accents and how many spaces separate its words.
+func (a *App) SearchLyrics(key models.SongKey, query string) (models.LyricsSearch, error) {
+	log := a.logger.With(
+		slog.String("OP", "SearchLyrics"),
+	)
+	log.Info("SearchLyrics called with song" + fmt.Sprintf(" %v %q", key, query))
+
+	phrase := []rune(fuzzy.Fold(strings.Join(strings.Fields(query), " ")))
+	if len(phrase) == 0 {
+		return models.LyricsSearch{}, models.Invalid("query_required", "query is required")
+	}
+
+	id, err := a.songID(key)
+	if err != nil {
+		log.Debug("Error resolving song" + fmt.Sprintf(" %v", key))
+		return models.LyricsSearch{}, err
+	}
+
+	text, err := a.db.GetText(id)
+	if err != nil {
+		log.Error("Error getting text for song" + fmt.Sprintf(" %d", id))
+		return models.LyricsSearch{}, fmt.Errorf("failed to get text for song: %w", err)
+	}
+
+	result := models.LyricsSearch{SongID: id, Query: query, Verses: []models.VerseMatch{}}
+	for _, verse := range splitVerses(string(text)) {
+		var matches []models.LineMatch
+		for i, line := range verse.Lines {
+			spans := findPhrase(line, phrase)
+			if len(spans) == 0 {
+				continue
+			}
+			matches = append(matches, models.LineMatch{Line: i + 1, Text: line, Spans: spans, Highlight: highlight(line, spans)})
+			result.TotalMatches += len(spans)
+		}
+		if len(matches) > 0 {
+			result.Verses = append(result.Verses, models.VerseMatch{Verse: verse, Matches: matches})
+		}
+	}
+
+	log.Info("SearchLyrics complete with" + fmt.Sprintf(" %d matches", result.TotalMatches))
+	return result, nil
+}
+
+// findPhrase returns where a folded phrase occurs in a line, without
+// overlaps. The line is folded one character at a time, with runs of spaces
+// made one space, so every folded character still knows the offset of the
+// character it came from.
+func findPhrase(line string, phrase []rune) []models.Span {
+	runes := []rune(line)
+	var folded []rune
+	var origin []int
+	for i, r := range runes {
+		if unicode.IsSpace(r) {
+			if len(folded) == 0 || folded[len(folded)-1] != ' ' {
+				folded = append(folded, ' ')
+				origin = append(origin, i)
+			}
+			continue
+		}
+		for _, f := range fuzzy.Fold(string(r)) {
+			folded = append(folded, f)
+			origin = append(origin, i)
+		}
+	}
+	// A span ends where the next folded character starts, so it keeps the
+	// accents that folding dropped after its last letter.
+	origin = append(origin, len(runes))
+
+	var spans []models.Span
+	for i := 0; i+len(phrase) <= len(folded); {
+		if !slices.Equal(folded[i:i+len(phrase)], phrase) {
+			i++
+			continue
+		}
+		spans = append(spans, models.Span{Start: origin[i], End: origin[i+len(phrase)]})
+		i += len(phrase)
+	}
+	return spans
+}
+
+// highlight wraps the spans of a line in <mark> tags. The lyrics are
+// escaped, so the only markup of the result is the tags.
+func highlight(line string, spans []models.Span) string {
+	runes := []rune(line)
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		b.WriteString(html.EscapeString(string(runes[last:s.Start])))
+		b.WriteString("<mark>" + html.EscapeString(string(runes[s.Start:s.End])) + "</mark>")
+		last = s.End
+	}
+	b.WriteString(html.EscapeString(string(runes[last:])))
+	return b.String()
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"testing"
+
+	"musicservice/interal/models"
+)
+
+func TestHighlightEscapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		spans []models.Span
+		want  string
+	}{
+		{"plain", "set my soul alight", []models.Span{{Start: 12, End: 18}}, "set my soul <mark>alight</mark>"},
+		{"markup around", `<b>soul</b> & "alight"`, []models.Span{{Start: 3, End: 7}}, `&lt;b&gt;<mark>soul</mark>&lt;/b&gt; &amp; &#34;alight&#34;`},
+		{"markup inside", `a <script>x</script>`, []models.Span{{Start: 2, End: 20}}, `a <mark>&lt;script&gt;x&lt;/script&gt;</mark>`},
+		{"no spans", `it's <i>`, nil, `it&#39;s &lt;i&gt;`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.line, tt.spans); got != tt.want {
+				t.Errorf("highlight = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"client"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/metadata"
+)
+
+// MetadataProvider is a source of the details of songs. A provider that
+// does not know a song returns an error of the not found kind.
+type MetadataProvider interface {
+	Name() string
+	SongInfo(ctx context.Context, group, song string) (client.SongDetail, error)
+}
+
+// fetchInfo asks the providers in order for the details of a song. Each
+// detail comes from the first provider that has it, so a provider missing
+// some details only has them filled in by the ones after it, and providers
+// that fail are passed over. The song is not found when no provider has any
+// of its details and none of them failed; otherwise the first failure is
+// returned.
+func (a *App) fetchInfo(ctx context.Context, group, song string) (client.SongDetail, error) {
+	log := a.logger.With(
+		slog.String("OP", "fetchInfo"),
+	)
+
+	var detail client.SongDetail
+	var found bool
+	var failed error
+	for _, p := range a.providers {
+		data, err := p.SongInfo(ctx, group, song)
+		if errors.Is(err, models.ErrNotFound) {
+			log.Debug("Song info not found by " + p.Name() + fmt.Sprintf(" %s %s", group, song))
+			continue
+		}
+		if err != nil {
+			log.Warn("Error getting song info from " + p.Name() + fmt.Sprintf(" %s %s: %v", group, song, err))
+			if failed == nil {
+				failed = err
+			}
+			continue
+		}
+
+		found = true
+		merge(&detail, data)
+		if complete(detail) {
+			break
+		}
+	}
+
+	switch {
+	case found:
+		return detail, nil
+	case failed != nil:
+		return client.SongDetail{}, failed
+	}
+	return client.SongDetail{}, models.NotFound(metadata.ErrNotFound.Code, "no song info for %q of group %q", song, group)
+}
+
+// merge fills the details missing from dst with those of src.
+func merge(dst *client.SongDetail, src client.SongDetail) {
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&dst.ReleaseDate, src.ReleaseDate},
+		{&dst.Text, src.Text},
+		{&dst.Link, src.Link},
+	} {
+		if *f.dst == "" {
+			*f.dst = f.src
+		}
+	}
+}
+
+func complete(detail client.SongDetail) bool {
+	return detail.ReleaseDate != "" && detail.Text != "" && detail.Link != ""
+}
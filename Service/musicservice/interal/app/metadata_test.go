@@ -0,0 +1,144 @@
+package app
+
+import (
+	"client"
+	"context"
+	"errors"
+	"testing"
+
+	"musicservice/interal/models"
+)
+
+// countingProvider is a stubProvider counting the calls it answers.
+type countingProvider struct {
+	stubProvider
+	calls *int
+}
+
+func (p countingProvider) SongInfo(ctx context.Context, group, song string) (client.SongDetail, error) {
+	*p.calls++
+	return p.stubProvider.SongInfo(ctx, group, song)
+}
+
+func TestFetchInfo(t *testing.T) {
+	notFound := models.NotFound("song_info_not_found", "no song info")
+	down := models.Upstream("upstream_failed", "service is down")
+	broken := models.Upstream("upstream_broken", "service is broken")
+
+	tests := []struct {
+		name      string
+		providers []stubProvider
+		want      client.SongDetail
+		err       error
+	}{
+		{
+			name: "first provider has everything",
+			providers: []stubProvider{
+				{name: "a", detail: client.SongDetail{ReleaseDate: "01.12.2003", Text: "a text", Link: "a link"}},
+				{name: "b", detail: client.SongDetail{ReleaseDate: "02.02.2002", Text: "b text", Link: "b link"}},
+			},
+			want: client.SongDetail{ReleaseDate: "01.12.2003", Text: "a text", Link: "a link"},
+		},
+		{
+			name: "partial details merged, first provider wins per field",
+			providers: []stubProvider{
+				{name: "a", detail: client.SongDetail{Text: "a text"}},
+				{name: "b", detail: client.SongDetail{Text: "b text", Link: "b link"}},
+				{name: "c", detail: client.SongDetail{ReleaseDate: "03.03.2003", Link: "c link"}},
+			},
+			want: client.SongDetail{ReleaseDate: "03.03.2003", Text: "a text", Link: "b link"},
+		},
+		{
+			name: "failing provider skipped",
+			providers: []stubProvider{
+				{name: "a", err: down},
+				{name: "b", detail: client.SongDetail{ReleaseDate: "01.12.2003", Text: "b text", Link: "b link"}},
+			},
+			want: client.SongDetail{ReleaseDate: "01.12.2003", Text: "b text", Link: "b link"},
+		},
+		{
+			name: "not found provider skipped",
+			providers: []stubProvider{
+				{name: "a", err: notFound},
+				{name: "b", detail: client.SongDetail{Text: "b text"}},
+			},
+			want: client.SongDetail{Text: "b text"},
+		},
+		{
+			name: "partial details kept when a later provider fails",
+			providers: []stubProvider{
+				{name: "a", detail: client.SongDetail{Text: "a text"}},
+				{name: "b", err: down},
+			},
+			want: client.SongDetail{Text: "a text"},
+		},
+		{
+			name: "all not found",
+			providers: []stubProvider{
+				{name: "a", err: notFound},
+				{name: "b", err: notFound},
+			},
+			err: models.ErrNotFound,
+		},
+		{
+			name: "not found and failure returns the failure",
+			providers: []stubProvider{
+				{name: "a", err: notFound},
+				{name: "b", err: down},
+			},
+			err: down,
+		},
+		{
+			name: "first failure returned",
+			providers: []stubProvider{
+				{name: "a", err: down},
+				{name: "b", err: broken},
+				{name: "c", err: notFound},
+			},
+			err: down,
+		},
+		{
+			name: "no providers",
+			err:  models.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newApp(t)
+			a.providers = nil
+			for _, p := range tt.providers {
+				a.providers = append(a.providers, p)
+			}
+
+			got, err := a.fetchInfo(context.Background(), "Muse", "Hysteria")
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchInfoStopsWhenComplete(t *testing.T) {
+	a, _ := newApp(t)
+	var first, second int
+	a.providers = []MetadataProvider{
+		countingProvider{stubProvider{name: "a", detail: client.SongDetail{ReleaseDate: "01.12.2003", Text: "a text", Link: "a link"}}, &first},
+		countingProvider{stubProvider{name: "b", detail: client.SongDetail{Text: "b text"}}, &second},
+	}
+
+	if _, err := a.fetchInfo(context.Background(), "Muse", "Hysteria"); err != nil {
+		t.Fatal(err)
+	}
+	if first != 1 || second != 0 {
+		t.Errorf("providers called %d and %d times, want the second passed over", first, second)
+	}
+}
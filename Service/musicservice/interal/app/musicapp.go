@@ -2,33 +2,154 @@ package app
 
 import (
 	"client"
-	"context"
 	"fmt"
-	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"log/slog"
 	"musicservice/interal/models"
-	"musicservice/pkg/sql/postgres"
+	"musicservice/pkg/lang"
+)
+
+// SongStore is the storage the App reads songs from and writes them to.
+type SongStore interface {
+	GetSongs(filter map[string]string, page models.Page) ([]models.Song, int, error)
+	ExportSongs(filter map[string]string, withText bool, fn func(models.Song) error) error
+	SongIDs(group, song string) ([]uint64, error)
+	GetSong(id uint64) (models.Song, error)
+	GetText(id uint64) ([]byte, error)
+	UpdateSong(id uint64, values map[string]string) error
+	DeleteSong(id uint64) error
+	SaveGroup(group string) error
+	QueueSong(song models.NewSong) (uint64, error)
+	ClaimEnrichments(limit int, lease time.Duration) ([]models.EnrichmentJob, error)
+	CompleteEnrichment(id uint64, data client.SongDetail, language string) error
+	FailEnrichment(id uint64, reason string, retryAt time.Time) error
+	Enrichment(id uint64) (models.Enrichment, error)
+	RetryEnrichment(id uint64) error
+	TrashedSongs(page models.Page) ([]models.TrashedSong, int, error)
+	RestoreSong(id uint64) error
+	PurgeSongs(before time.Time) (int64, error)
+	Revisions(songID uint64) ([]models.Revision, error)
+	Revision(songID, revisionID uint64) (models.Revision, error)
+	RollbackSong(songID, revisionID uint64) error
+	Groups(page models.Page) ([]models.Group, int, error)
+	Group(name string) (models.Group, error)
+	RenameGroup(name, newName string) error
+	MergeGroups(name, into string) error
+	DeleteGroup(name string) error
+}
+
+// ErrUnsupportedLanguage is returned for a language search cannot be done in.
+var ErrUnsupportedLanguage = models.Invalid("unsupported_language", "unsupported language")
+
+// ErrInvalidFilter is returned for a filter that cannot be searched by.
+var ErrInvalidFilter = models.Invalid("invalid_filter", "invalid filter")
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
 )
 
 type App struct {
 	logger *slog.Logger
-	db *postgres.Postgres
-	client *client.ClientWithResponses
+	db SongStore
+	providers []MetadataProvider
+	retention time.Duration
+	threshold float64
+	importWorkers int
+	resync *resyncState
 }
 
-func NewApp(log *slog.Logger, db *postgres.Postgres, client *client.ClientWithResponses) *App {
-    return &App{logger: log, db: db, client: client}
+// NewApp creates the App. Deleted songs stay in the trash for retention
+// before PurgeTrash removes them for good; fuzzy searches match names at
+// least threshold similar unless the filter sets its own threshold; bulk
+// imports create at most importWorkers songs at a time. The details of
+// songs are asked of providers in order.
+func NewApp(log *slog.Logger, db SongStore, providers []MetadataProvider, retention time.Duration, threshold float64, importWorkers int) *App {
+    return &App{logger: log, db: db, providers: providers, retention: retention, threshold: threshold, importWorkers: max(importWorkers, 1), resync: &resyncState{}}
 }
 
-func (a *App) GetDataMusic(filter models.FilterSong, frstpg, limcnt int) ([]models.Song, error) {
+// pageLimit clamps a requested page size to (0, MaxPageSize], defaulting
+// to DefaultPageSize.
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageSize
+	}
+	return min(limit, MaxPageSize)
+}
+
+// GetDataMusic returns one page of the songs matching the filter. The
+// lyrics of every song are cut to page textPage of textLimit verses.
+func (a *App) GetDataMusic(filter models.FilterSong, page models.Page, textPage, textLimit int) (models.SongsPage, error) {
 	log := a.logger.With(
 		slog.String("OP", "GetDataMusic"),
 	)
 
 	log.Info("GetDataMusic called with filter " + fmt.Sprintf(" %v", filter))
 	
+	filtermap, err := a.filterMap(filter)
+	if err != nil {
+		return models.SongsPage{}, err
+	}
+
+	if textPage < 1 || textLimit < 1 {
+		return models.SongsPage{}, fmt.Errorf("%w: page %d of size %d", ErrInvalidPage, textPage, textLimit)
+	}
+
+	page.Order, err = parseSort(page.Sort)
+	if err != nil {
+		return models.SongsPage{}, err
+	}
+	for _, key := range page.Order {
+		if key.Field == models.SortRelevance && filter.Text == "" {
+			return models.SongsPage{}, fmt.Errorf("%w: relevance needs a text filter", ErrInvalidSort)
+		}
+	}
+
+	if err := applyCursor(&page); err != nil {
+		return models.SongsPage{}, err
+	}
+
+	page.Limit = pageLimit(page.Limit)
+
+	// One extra row tells whether another page follows.
+	limit := page.Limit
+	page.Limit++
+
+	songs, total, err := a.db.GetSongs(filtermap, page)
+    if err!= nil {
+        log.Error("Error getting songs" + fmt.Sprintf(" %v", filter))
+        return models.SongsPage{}, fmt.Errorf("failed to get songs: %w", err)
+    }
+
+	result := models.SongsPage{Songs: songs, Total: total}
+	if len(songs) > limit {
+		result.Songs = songs[:limit]
+		byID := keyset(page.Order)
+		if len(page.Order) == 0 {
+			_, ranked := filtermap["text"]
+			byID = !ranked && filtermap["match"] != models.MatchFuzzy
+		}
+		if byID {
+			id, _ := strconv.ParseUint(result.Songs[limit-1].ID, 10, 64)
+			result.NextCursor = encodeCursor(cursorID, id)
+		} else {
+			result.NextCursor = encodeCursor(cursorOffset, uint64(page.Offset+limit))
+		}
+	}
+
+	for i, s := range result.Songs {
+		result.Songs[i].Text = versePage(splitVerses(s.Text), textPage, textLimit).Text()
+    }
+	
+    log.Info("GetDataMusic complete with" + fmt.Sprintf(" %d songs", len(result.Songs)))
+    return result, nil
+}
+
+// filterMap validates a filter and turns it into the filter keys of the
+// SongStore.
+func (a *App) filterMap(filter models.FilterSong) (map[string]string, error) {
 	filtermap := make(map[string]string)
 	
 	if filter.Group != "" {
@@ -41,97 +162,228 @@ func (a *App) GetDataMusic(filter models.FilterSong, frstpg, limcnt int) ([]mode
         filtermap["link"] = filter.Link
     }
 	if filter.ReleaseDate != "" {
+		if _, err := time.Parse(dateLayout, filter.ReleaseDate); err != nil {
+			return nil, fmt.Errorf("%w: releaseDate %q is not a DD.MM.YYYY date", ErrInvalidFilter, filter.ReleaseDate)
+		}
         filtermap["releasedate"] = filter.ReleaseDate
     }
+	err := releaseRange(filter, filtermap)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Language != "" {
+		if !lang.Supported(filter.Language) {
+			return nil, fmt.Errorf("%w %q", ErrUnsupportedLanguage, filter.Language)
+		}
+		filtermap["language"] = filter.Language
+	}
 	if filter.Text != "" {
         filtermap["text"] = filter.Text
+
+		textLanguage := filter.TextLanguage
+		if textLanguage == "" || textLanguage == "auto" {
+			textLanguage = lang.Detect(filter.Text)
+		} else if !lang.Supported(textLanguage) {
+			return nil, fmt.Errorf("%w %q", ErrUnsupportedLanguage, textLanguage)
+		}
+		filtermap["textlanguage"] = textLanguage
     }
 
-	
+	switch filter.Match {
+	case "", models.MatchExact:
+	case models.MatchFuzzy:
+		if filter.Group == "" && filter.Song == "" {
+			return nil, fmt.Errorf("%w: fuzzy match needs a group or song", ErrInvalidFilter)
+		}
 
-    songs, err := a.db.GetSongs(filtermap)
-    if err!= nil {
-        log.Error("Error getting songs" + fmt.Sprintf(" %v", filter))
-        return nil, fmt.Errorf("failed to get songs: %w", err)
-    }
+		threshold := a.threshold
+		if filter.Threshold != 0 {
+			threshold = filter.Threshold
+		}
+		if threshold <= 0 || threshold > 1 {
+			return nil, fmt.Errorf("%w: threshold must be in (0, 1]", ErrInvalidFilter)
+		}
+		filtermap["match"] = models.MatchFuzzy
+		filtermap["threshold"] = strconv.FormatFloat(threshold, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("%w: unknown match %q", ErrInvalidFilter, filter.Match)
+	}
 
-    if len(songs) == 0 {
-        log.Error("No songs found with filter" + fmt.Sprintf(" %v", filter))
-        return nil, fmt.Errorf("no songs found with filter: %w", err)
-    }
+	return filtermap, nil
+}
 
-	for i, s := range songs {
-		songs[i].Text, err = Pangination(s.Text, frstpg, limcnt)
+const dateLayout = "02.01.2006"
+
+// releaseRange narrows the filter to the songs released within every one of
+// the range, year and decade the filter asks for. They all become one
+// inclusive range of release dates, which the stores can answer from the
+// release date index.
+func releaseRange(filter models.FilterSong, filtermap map[string]string) error {
+	var from, to time.Time
+	narrow := func(start, end time.Time) {
+		if !start.IsZero() && (from.IsZero() || start.After(from)) {
+			from = start
+		}
+		if !end.IsZero() && (to.IsZero() || end.Before(to)) {
+			to = end
+		}
+	}
+
+	if filter.ReleasedFrom != "" {
+		start, err := time.Parse(dateLayout, filter.ReleasedFrom)
 		if err != nil {
-			return nil, fmt.Errorf("failed to paginate song text: %w", err)
+			return fmt.Errorf("%w: releasedFrom %q is not a DD.MM.YYYY date", ErrInvalidFilter, filter.ReleasedFrom)
 		}
-    }
-	
-    log.Info("GetDataMusic complete with" + fmt.Sprintf(" %d songs", len(songs)))
-    return songs, nil
+		narrow(start, time.Time{})
+	}
+	if filter.ReleasedTo != "" {
+		end, err := time.Parse(dateLayout, filter.ReleasedTo)
+		if err != nil {
+			return fmt.Errorf("%w: releasedTo %q is not a DD.MM.YYYY date", ErrInvalidFilter, filter.ReleasedTo)
+		}
+		narrow(time.Time{}, end)
+	}
+	if filter.ReleasedFrom != "" && filter.ReleasedTo != "" && from.After(to) {
+		return fmt.Errorf("%w: releasedFrom is after releasedTo", ErrInvalidFilter)
+	}
+
+	if filter.Year != 0 {
+		if filter.Year < 1 || filter.Year > 9999 {
+			return fmt.Errorf("%w: year %d is out of range", ErrInvalidFilter, filter.Year)
+		}
+		start := time.Date(filter.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		narrow(start, start.AddDate(1, 0, -1))
+	}
+	if filter.Decade != 0 {
+		if filter.Decade < 0 || filter.Decade > 9990 || filter.Decade%10 != 0 {
+			return fmt.Errorf("%w: decade %d must be a year ending in 0, like 1990", ErrInvalidFilter, filter.Decade)
+		}
+		start := time.Date(filter.Decade, time.January, 1, 0, 0, 0, 0, time.UTC)
+		narrow(start, start.AddDate(10, 0, -1))
+	}
+
+	if !from.IsZero() {
+		filtermap["releasedfrom"] = from.Format(dateLayout)
+	}
+	if !to.IsZero() {
+		filtermap["releasedto"] = to.Format(dateLayout)
+	}
+	return nil
 }
 
-func (a *App) GetTextSong(song string, page, limit int) ([]byte, error) {
+// songID resolves a song key into the id of exactly one song.
+func (a *App) songID(key models.SongKey) (uint64, error) {
+	if key.ID != 0 {
+		return key.ID, nil
+	}
+	if key.Song == "" {
+		return 0, models.Invalid("song_key_required", "song id or name is required")
+	}
+
+	ids, err := a.db.SongIDs(key.Group, key.Song)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up song: %w", err)
+	}
+
+	switch len(ids) {
+	case 0:
+		return 0, models.ErrSongNotFound
+	case 1:
+		return ids[0], nil
+	default:
+		return 0, models.Conflict("song_ambiguous", "song %q exists in %d groups, group is required", key.Song, len(ids))
+	}
+}
+
+// GetSong returns one song with its full lyrics.
+func (a *App) GetSong(id uint64) (models.Song, error) {
 	log := a.logger.With(
-		slog.String("OP", "GetTextSong"),
+		slog.String("OP", "GetSong"),
 	)
-	log.Info("GetTextSong called with song " + fmt.Sprintf(" %s", song))
+	log.Info("GetSong called with song" + fmt.Sprintf(" %d", id))
+
+	song, err := a.db.GetSong(id)
+	if err != nil {
+		log.Debug("Error getting song" + fmt.Sprintf(" %d", id))
+		return models.Song{}, fmt.Errorf("failed to get song: %w", err)
+	}
+
+	log.Info("GetSong complete" + fmt.Sprintf(" %d", id))
+	return song, nil
+}
 
-	text, err := a.db.GetText(song)
+// GetLyrics returns one page of the verses of a song's lyrics, page
+// counting from 1 and size verses to a page.
+func (a *App) GetLyrics(key models.SongKey, page, size int) (models.Lyrics, error) {
+	log := a.logger.With(
+		slog.String("OP", "GetLyrics"),
+	)
+	log.Info("GetLyrics called with song " + fmt.Sprintf(" %v", key))
+
+	if page < 1 || size < 1 {
+		return models.Lyrics{}, fmt.Errorf("%w: page %d of size %d", ErrInvalidPage, page, size)
+	}
+
+	id, err := a.songID(key)
+	if err != nil {
+		log.Debug("Error resolving song" + fmt.Sprintf(" %v", key))
+		return models.Lyrics{}, err
+	}
+
+	text, err := a.db.GetText(id)
 	if err!= nil {
-        log.Error("Error getting text for song" + fmt.Sprintf(" %s", song))
-        return nil, fmt.Errorf("failed to get text for song: %w", err)
+        log.Error("Error getting text for song" + fmt.Sprintf(" %d", id))
+        return models.Lyrics{}, fmt.Errorf("failed to get text for song: %w", err)
     }
 
 	if len(text) == 0 {
-        log.Debug("Text not found for song" + fmt.Sprintf(" %s", song))
-        return nil, fmt.Errorf("text not found for song: %w", err)
-    }
-
-	texts, err := Pangination(string(text), page, limit)
-	if err!= nil {
-        return nil, fmt.Errorf("failed to paginate song text: %w", err)
+        log.Debug("Text not found for song" + fmt.Sprintf(" %d", id))
+        return models.Lyrics{}, models.NotFound("text_not_found", "text not found for song %d", id)
     }
 
-	text = []byte(texts)
+	lyrics := versePage(splitVerses(string(text)), page, size)
+	lyrics.SongID = id
 
-	log.Info("GetTextSong complete" + fmt.Sprintf(" %s", song))
-	return text, nil
+	log.Info("GetLyrics complete" + fmt.Sprintf(" %d", id))
+	return lyrics, nil
 }
 
-func (a *App) DeleteSong(song string) (error) {
+func (a *App) DeleteSong(key models.SongKey) (error) {
 	log := a.logger.With(
 		slog.String("OP", "DeleteSong"),
 	)
-	log.Info("DeleteSong called with song" + fmt.Sprintf(" %s", song))
+	log.Info("DeleteSong called with song" + fmt.Sprintf(" %v", key))
+
+	id, err := a.songID(key)
+	if err != nil {
+		log.Debug("Error resolving song" + fmt.Sprintf(" %v", key))
+		return err
+	}
 
-	err := a.db.DeleteSong(song)
+	err = a.db.DeleteSong(id)
 	if err!= nil {
-        log.Debug("Error deleting song" + fmt.Sprintf(" %s", song))
+        log.Debug("Error deleting song" + fmt.Sprintf(" %d", id))
         return fmt.Errorf("failed to delete song: %w", err)
     }
 
-	log.Info("Song deleted" + fmt.Sprintf(" %s", song))
+	log.Info("Song deleted" + fmt.Sprintf(" %d", id))
 	return nil
 }
 
-func (a *App) UpdateSong(song models.FilterSong) (error) {
+// UpdateSong sets the non-empty fields of song on the song identified by
+// key. The title is part of the key and is not changed.
+func (a *App) UpdateSong(key models.SongKey, song models.FilterSong) (error) {
 	log := a.logger.With(
 		slog.String("OP", "UpdateSong"),
 	)
 
-	log.Info("UpdateSong called with song" + fmt.Sprintf(" %s", song))
+	log.Info("UpdateSong called with song" + fmt.Sprintf(" %v %v", key, song))
 
 	songmap := make(map[string]string)
 	
 	if song.Group != "" {
         songmap["group"] = song.Group
     }
-	if song.Song != "" {
-        songmap["song"] = song.Song
-    } else {
-		return fmt.Errorf("song name is required")
-	}
 	if song.Link != "" {
         songmap["link"] = song.Link
     }
@@ -141,17 +393,31 @@ func (a *App) UpdateSong(song models.FilterSong) (error) {
 	if song.Text != "" {
         songmap["text"] = song.Text
     }
+	if song.Language != "" {
+		if !lang.Supported(song.Language) {
+			return fmt.Errorf("%w %q", ErrUnsupportedLanguage, song.Language)
+		}
+		songmap["language"] = song.Language
+	}
 
-	err := a.db.UpdateSong(songmap)
+	id, err := a.songID(key)
 	if err != nil {
-        log.Debug("Error updating song" + fmt.Sprintf(" %s", song.Song))
+		log.Debug("Error resolving song" + fmt.Sprintf(" %v", key))
+		return err
+	}
+
+	err = a.db.UpdateSong(id, songmap)
+	if err != nil {
+        log.Debug("Error updating song" + fmt.Sprintf(" %d", id))
         return fmt.Errorf("failed to update song: %w", err)
     }
 
-	log.Info("Song updated" + fmt.Sprintf(" %s", song.Song))
+	log.Info("Song updated" + fmt.Sprintf(" %d", id))
 	return nil
 }
 
+// CreateSong saves a song right away and queues it for enrichment, which
+// fetches its details from the info service in the background.
 func (a *App) CreateSong(newsong models.NewSong) (uint64, error) {
 	log := a.logger.With(
 		slog.String("OP", "CreateSong"),
@@ -159,34 +425,19 @@ func (a *App) CreateSong(newsong models.NewSong) (uint64, error) {
 
 	log.Info("Creating new song" + fmt.Sprintf(" %s %s", newsong.Group, newsong.Song))
 
-	resp, err := a.client.GetInfoWithResponse(context.TODO(), &client.GetInfoParams{Group: newsong.Group, Song: newsong.Song})
-	if err != nil {
-		log.Debug("Error getting info" + fmt.Sprintf(" %s %s", newsong.Group, newsong.Song))
-		return 0, fmt.Errorf("failed to get song info: %w", err)
+	if newsong.Group == "" || newsong.Song == "" {
+		return 0, models.Invalid("song_key_required", "group and song are required")
 	}
-
-	if resp.StatusCode() != http.StatusOK {
-		log.Debug("Expected HTTP 200 but recerved" + fmt.Sprintf(" %s %s %d", newsong.Group, newsong.Song, resp.StatusCode()))
-        return 0, fmt.Errorf("failed to get song info: status code %d", resp.StatusCode())
+	if newsong.Language != "" && !lang.Supported(newsong.Language) {
+		return 0, fmt.Errorf("%w %q", ErrUnsupportedLanguage, newsong.Language)
 	}
 
-	id, err := a.db.SaveMusic(newsong, *resp.JSON200)
+	id, err := a.db.QueueSong(newsong)
 	if err != nil {
-        log.Debug("Error saving music" + fmt.Sprintf(" %s %s", newsong.Group, newsong.Song))
-        return 0, fmt.Errorf("failed to save song: %w", err)
-    }
+		log.Debug("Error saving music" + fmt.Sprintf(" %s %s", newsong.Group, newsong.Song))
+		return 0, fmt.Errorf("failed to save song: %w", err)
+	}
 
-	log.Info("New song created" + fmt.Sprintf(" %s %s ID: %d", newsong.Group, newsong.Song, id))
+	log.Info("New song queued for enrichment" + fmt.Sprintf(" %s %s ID: %d", newsong.Group, newsong.Song, id))
 	return id, nil
 }
-
-func Pangination(text string, page, limit int) (string, error) {
-	paragr := strings.Split(text, "\n\n")
-
-	if limit > len(paragr) {
-		paragr = paragr[page-1:]
-		return strings.Join(paragr, "\n\n") + "\n\n... (Page " + fmt.Sprintf("%d", page) + " of " + fmt.Sprintf("%d", len(paragr)) + ")", nil
-	}
-	
-	return strings.Join(paragr[page-1:page+limit-1], "\n\n") + "\n\n... (Page " + fmt.Sprintf("%d", page) + " of " + fmt.Sprintf("%d", page + limit) + ")", nil
-}
\ No newline at end of file
@@ -0,0 +1,207 @@
+package app
+
+import (
+	"client"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/sql/memory"
+)
+
+// newApp returns an App on an empty in-memory store.
+func newApp(t *testing.T) (*App, *memory.Memory) {
+	t.Helper()
+
+	db := memory.NewMemory()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewApp(logger, db, nil, 0, 0.3, 1), db
+}
+
+func TestGetDataMusicEmpty(t *testing.T) {
+	a, _ := newApp(t)
+
+	result, err := a.GetDataMusic(models.FilterSong{Group: "Nobody"}, models.Page{}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 0 || result.Songs == nil || len(result.Songs) != 0 {
+		t.Errorf("got %+v, want an empty page", result)
+	}
+}
+
+// addSong creates a song and saves the details enrichment would fetch.
+func addSong(t *testing.T, a *App, db *memory.Memory, group, song, date, text string) uint64 {
+	t.Helper()
+
+	id, err := a.CreateSong(models.NewSong{Group: group, Song: song})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CompleteEnrichment(id, client.SongDetail{ReleaseDate: date, Text: text, Link: "https://example.com"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestCreateSong(t *testing.T) {
+	a, db := newApp(t)
+
+	tests := []struct {
+		name string
+		song models.NewSong
+		err  error
+	}{
+		{"valid", models.NewSong{Group: "Muse", Song: "Hysteria"}, nil},
+		{"with language", models.NewSong{Group: "Rammstein", Song: "Sonne", Language: "german"}, nil},
+		{"no group", models.NewSong{Song: "Hysteria"}, models.ErrValidation},
+		{"no song", models.NewSong{Group: "Muse"}, models.ErrValidation},
+		{"unsupported language", models.NewSong{Group: "Muse", Song: "Hysteria", Language: "klingon"}, ErrUnsupportedLanguage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := a.CreateSong(tt.song)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			status, err := db.Enrichment(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.Status != models.EnrichmentPending {
+				t.Errorf("enrichment = %s, want %s", status.Status, models.EnrichmentPending)
+			}
+		})
+	}
+}
+
+func TestGetDataMusic(t *testing.T) {
+	a, db := newApp(t)
+	addSong(t, a, db, "Muse", "Hysteria", "01.12.2003", "It's bugging me\n\nGrating me")
+	addSong(t, a, db, "Muse", "Supermassive Black Hole", "16.07.2006", "Ooh baby")
+	addSong(t, a, db, "Queen", "Bohemian Rhapsody", "31.10.1975", "Is this the real life?")
+
+	tests := []struct {
+		name   string
+		filter models.FilterSong
+		want   []string
+		err    error
+	}{
+		{"group", models.FilterSong{Group: "Muse"}, []string{"Hysteria", "Supermassive Black Hole"}, nil},
+		{"year", models.FilterSong{Year: 2006}, []string{"Supermassive Black Hole"}, nil},
+		{"decade", models.FilterSong{Decade: 1970}, []string{"Bohemian Rhapsody"}, nil},
+		{"fuzzy", models.FilterSong{Song: "bohemain rapsody", Match: models.MatchFuzzy}, []string{"Bohemian Rhapsody"}, nil},
+		{"invalid date", models.FilterSong{ReleaseDate: "2006-07-16"}, nil, models.ErrValidation},
+		{"unsupported language", models.FilterSong{Language: "klingon"}, nil, models.ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := a.GetDataMusic(tt.filter, models.Page{}, 1, 10)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, s := range result.Songs {
+				got = append(got, s.Song)
+			}
+			if len(got) != len(tt.want) || result.Total != len(tt.want) {
+				t.Fatalf("got %q of %d, want %q", got, result.Total, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetDataMusicPages(t *testing.T) {
+	a, db := newApp(t)
+	for _, song := range []string{"A", "B", "C", "D", "E"} {
+		addSong(t, a, db, "Group", song, "", "first verse\n\nsecond verse")
+	}
+
+	var got []string
+	page := models.Page{Limit: 2}
+	for range 5 {
+		result, err := a.GetDataMusic(models.FilterSong{}, page, 2, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range result.Songs {
+			got = append(got, s.Song)
+			if s.Text != "second verse" {
+				t.Errorf("text = %q, want the second verse only", s.Text)
+			}
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		page.Cursor = result.NextCursor
+	}
+
+	want := []string{"A", "B", "C", "D", "E"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestUpdateSong(t *testing.T) {
+	a, db := newApp(t)
+	id := addSong(t, a, db, "Muse", "Hysteria", "01.12.2003", "")
+	addSong(t, a, db, "Muse Tribute", "Hysteria", "", "")
+
+	err := a.UpdateSong(models.SongKey{Group: "Muse", Song: "Hysteria"}, models.FilterSong{Link: "https://example.com/new", Language: "french"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	song, err := a.GetSong(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.Link != "https://example.com/new" || song.Language != "french" || song.ReleaseDate != "01.12.2003" {
+		t.Errorf("got %+v", song)
+	}
+
+	tests := []struct {
+		name string
+		key  models.SongKey
+		song models.FilterSong
+		err  error
+	}{
+		{"ambiguous title", models.SongKey{Song: "Hysteria"}, models.FilterSong{Link: "x"}, models.ErrConflict},
+		{"missing song", models.SongKey{Group: "Muse", Song: "Uprising"}, models.FilterSong{Link: "x"}, models.ErrNotFound},
+		{"no key", models.SongKey{}, models.FilterSong{Link: "x"}, models.ErrValidation},
+		{"nothing to update", models.SongKey{ID: id}, models.FilterSong{}, models.ErrNothingToUpdate},
+		{"unsupported language", models.SongKey{ID: id}, models.FilterSong{Language: "klingon"}, ErrUnsupportedLanguage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := a.UpdateSong(tt.key, tt.song); !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
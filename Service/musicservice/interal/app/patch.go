@@ -0,0 +1,120 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/lang"
+)
+
+// patchFields maps the fields of a SongPatch to the columns they update.
+var patchFields = map[string]string{
+	"group":       "group",
+	"song":        "song",
+	"releaseDate": "releasedate",
+	"text":        "text",
+	"link":        "link",
+	"language":    "language",
+}
+
+// PatchSong applies a JSON merge patch to the song with the given id. Every
+// field is checked before anything is written, so a patch is applied whole
+// or not at all.
+func (a *App) PatchSong(id uint64, patch models.SongPatch) error {
+	log := a.logger.With(
+		slog.String("OP", "PatchSong"),
+	)
+
+	log.Info("PatchSong called with song" + fmt.Sprintf(" %d %v", id, patchString(patch)))
+
+	songmap, err := patchValues(patch)
+	if err != nil {
+		log.Debug("Error validating patch" + fmt.Sprintf(" %d: %v", id, err))
+		return err
+	}
+
+	err = a.db.UpdateSong(id, songmap)
+	if err != nil {
+		log.Debug("Error patching song" + fmt.Sprintf(" %d", id))
+		return fmt.Errorf("failed to patch song: %w", err)
+	}
+
+	log.Info("Song patched" + fmt.Sprintf(" %d", id))
+	return nil
+}
+
+// patchValues turns a merge patch into the column values UpdateSong sets,
+// an empty value clearing its column.
+func patchValues(patch models.SongPatch) (map[string]string, error) {
+	if len(patch) == 0 {
+		return nil, models.ErrNothingToUpdate
+	}
+
+	songmap := make(map[string]string, len(patch))
+	for field, value := range patch {
+		column, ok := patchFields[field]
+		if !ok {
+			return nil, models.Invalid("unknown_field", "unknown field %q", field)
+		}
+
+		v := ""
+		if value != nil {
+			v = strings.TrimSpace(*value)
+		}
+
+		switch field {
+		case "group", "song":
+			if v == "" {
+				return nil, models.Invalid("field_required", "%s cannot be cleared", field)
+			}
+		case "releaseDate":
+			if v != "" {
+				if _, err := time.Parse(dateLayout, v); err != nil {
+					return nil, fmt.Errorf("%w %q, want DD.MM.YYYY", models.ErrInvalidDate, v)
+				}
+			}
+		case "link":
+			if v != "" && !validLink(v) {
+				return nil, models.Invalid("invalid_link", "invalid link %q, want an http or https URL", v)
+			}
+		case "language":
+			if v == "" {
+				v = lang.Default
+			} else if !lang.Supported(v) {
+				return nil, fmt.Errorf("%w %q", ErrUnsupportedLanguage, v)
+			}
+		case "text":
+			// Lyrics keep their spacing; only an all-blank text clears them.
+			if v != "" {
+				v = *value
+			}
+		}
+		songmap[column] = v
+	}
+	return songmap, nil
+}
+
+func validLink(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// patchString describes a patch for the log, with nulls spelled out.
+func patchString(patch models.SongPatch) string {
+	fields := make([]string, 0, len(patch))
+	for field, value := range patch {
+		if value == nil {
+			fields = append(fields, field+":null")
+		} else {
+			fields = append(fields, field+":"+strconv.Quote(*value))
+		}
+	}
+	sort.Strings(fields)
+	return "{" + strings.Join(fields, " ") + "}"
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"musicservice/interal/models"
+)
+
+// patch decodes a merge patch as the server does, so absent fields and
+// nulls come through as they would over HTTP.
+func patch(t *testing.T, body string) models.SongPatch {
+	t.Helper()
+
+	var p models.SongPatch
+	if err := json.Unmarshal([]byte(body), &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPatchSong(t *testing.T) {
+	original := models.Song{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com", Language: "german"}
+
+	tests := []struct {
+		name  string
+		patch string
+		want  models.Song
+	}{
+		{"absent fields untouched", `{"text": "Changed"}`, models.Song{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Text: "Changed", Link: "https://example.com", Language: "german"}},
+		{"null clears", `{"link": null, "releaseDate": null}`, models.Song{Group: "Muse", Song: "Hysteria", Text: "It's bugging me", Language: "german"}},
+		{"empty string clears", `{"link": "", "text": "  "}`, models.Song{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Language: "german"}},
+		{"cleared language restores the default", `{"language": null}`, models.Song{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com", Language: "english"}},
+		{"empty language restores the default", `{"language": ""}`, models.Song{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com", Language: "english"}},
+		{"title changed by id", `{"song": "Hysteria (Live)"}`, models.Song{Group: "Muse", Song: "Hysteria (Live)", ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com", Language: "german"}},
+		{"group changed by id", `{"group": "MUSE"}`, models.Song{Group: "MUSE", Song: "Hysteria", ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com", Language: "german"}},
+		{"lyrics keep their spacing", `{"text": "  Verse\n\n  Chorus\n"}`, models.Song{Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Text: "  Verse\n\n  Chorus\n", Link: "https://example.com", Language: "german"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, db := newApp(t)
+			id := addSong(t, a, db, original.Group, original.Song, original.ReleaseDate, original.Text)
+			if err := a.PatchSong(id, patch(t, `{"language": "german"}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := a.PatchSong(id, patch(t, tt.patch)); err != nil {
+				t.Fatal(err)
+			}
+			got, err := a.GetSong(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.ID = got.ID
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchSongRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		err   error
+	}{
+		{"group cleared with null", `{"group": null}`, models.ErrValidation},
+		{"group cleared with empty string", `{"group": " "}`, models.ErrValidation},
+		{"song cleared with null", `{"song": null}`, models.ErrValidation},
+		{"song cleared with empty string", `{"song": ""}`, models.ErrValidation},
+		{"invalid release date", `{"releaseDate": "2003-12-01"}`, models.ErrInvalidDate},
+		{"impossible release date", `{"releaseDate": "31.02.2003"}`, models.ErrInvalidDate},
+		{"invalid link", `{"link": "ftp://example.com"}`, models.ErrValidation},
+		{"unsupported language", `{"language": "klingon"}`, ErrUnsupportedLanguage},
+		{"unknown field", `{"rank": "1"}`, models.ErrValidation},
+		{"empty patch", `{}`, models.ErrNothingToUpdate},
+		{"title colliding with another song", `{"song": "Uprising"}`, models.ErrConflict},
+		{"group and title colliding with another song", `{"group": "Muse", "song": "Uprising"}`, models.ErrConflict},
+		{"one bad field rejects the whole patch", `{"text": "Changed", "releaseDate": "someday"}`, models.ErrInvalidDate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, db := newApp(t)
+			id := addSong(t, a, db, "Muse", "Hysteria", "01.12.2003", "It's bugging me")
+			addSong(t, a, db, "Muse", "Uprising", "07.09.2009", "Paranoia is in bloom")
+			before, err := a.GetSong(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = a.PatchSong(id, patch(t, tt.patch))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if after, _ := a.GetSong(id); after != before {
+				t.Errorf("song changed to %+v, want it left as %+v", after, before)
+			}
+		})
+	}
+}
+
+func TestPatchMissingSong(t *testing.T) {
+	a, _ := newApp(t)
+
+	err := a.PatchSong(1, patch(t, `{"text": "Changed"}`))
+	if !errors.Is(err, models.ErrSongNotFound) {
+		t.Errorf("err = %v, want %v", err, models.ErrSongNotFound)
+	}
+}
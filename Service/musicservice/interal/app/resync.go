@@ -0,0 +1,195 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/infocache"
+	"musicservice/pkg/metadata"
+)
+
+// DefaultResyncBatch is how many songs a resync reads at a time unless
+// told otherwise.
+const DefaultResyncBatch = 100
+
+// ErrResyncRunning is returned when a resync is asked for while one runs.
+var ErrResyncRunning = models.Conflict("resync_running", "a resync is already running")
+
+// resyncState is the resync running now and the report of the last one.
+// The App is copied by value, so it keeps the state behind a pointer.
+type resyncState struct {
+	mu      sync.Mutex
+	running bool
+	last    *models.ResyncReport
+}
+
+// LastResync returns the report of the last resync.
+func (a *App) LastResync() (models.ResyncReport, error) {
+	a.resync.mu.Lock()
+	defer a.resync.mu.Unlock()
+
+	if a.resync.last == nil {
+		return models.ResyncReport{}, models.NotFound("resync_not_found", "no resync has run yet")
+	}
+	return *a.resync.last, nil
+}
+
+// ResyncSongs compares the link, release date and lyrics of every song with
+// the info service, batch songs at a time, bypassing the info cache.
+// Differences are applied when apply is set and only reported otherwise;
+// either way the report is kept for LastResync. Songs still being enriched
+// are skipped, and the run stops early when the info service is unavailable
+// or ctx is done.
+func (a *App) ResyncSongs(ctx context.Context, batch int, apply bool) (models.ResyncReport, error) {
+	log := a.logger.With(
+		slog.String("OP", "ResyncSongs"),
+	)
+	log.Info("ResyncSongs called with" + fmt.Sprintf(" batch %d apply %t", batch, apply))
+
+	a.resync.mu.Lock()
+	if a.resync.running {
+		a.resync.mu.Unlock()
+		return models.ResyncReport{}, ErrResyncRunning
+	}
+	a.resync.running = true
+	a.resync.mu.Unlock()
+
+	report := models.ResyncReport{StartedAt: time.Now(), Applied: apply, Songs: []models.ResyncSong{}}
+	err := a.resyncAll(ctx, &report, batch, apply)
+	if err != nil {
+		log.Error("Error resyncing songs " + err.Error())
+		report.Error = err.Error()
+	}
+	report.FinishedAt = time.Now()
+
+	a.resync.mu.Lock()
+	a.resync.running = false
+	a.resync.last = &report
+	a.resync.mu.Unlock()
+
+	log.Info("ResyncSongs complete with" + fmt.Sprintf(" %d checked, %d changed, %d skipped, %d failed", report.Checked, report.Changed, report.Skipped, report.Failed))
+	if err != nil {
+		return report, fmt.Errorf("failed to resync songs: %w", err)
+	}
+	return report, nil
+}
+
+func (a *App) resyncAll(ctx context.Context, report *models.ResyncReport, batch int, apply bool) error {
+	if batch <= 0 {
+		batch = DefaultResyncBatch
+	}
+
+	page := models.Page{Limit: batch, Order: []models.SortKey{{Field: models.SortID}}}
+	for {
+		songs, _, err := a.db.GetSongs(map[string]string{}, page)
+		if err != nil {
+			return fmt.Errorf("failed to read songs: %w", err)
+		}
+
+		for _, song := range songs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			id, _ := strconv.ParseUint(song.ID, 10, 64)
+			err := a.resyncSong(ctx, report, id, song, apply)
+			if errors.Is(err, metadata.ErrUnavailable) {
+				return err
+			}
+		}
+
+		if len(songs) < batch {
+			return nil
+		}
+		page.After, _ = strconv.ParseUint(songs[len(songs)-1].ID, 10, 64)
+	}
+}
+
+func (a *App) resyncSong(ctx context.Context, report *models.ResyncReport, id uint64, song models.Song, apply bool) error {
+	status, err := a.db.Enrichment(id)
+	if err != nil || status.Status != models.EnrichmentDone {
+		report.Skipped++
+		return nil
+	}
+	report.Checked++
+
+	entry := models.ResyncSong{SongID: id, Group: song.Group, Song: song.Song}
+	data, err := a.fetchInfo(infocache.Refresh(ctx), song.Group, song.Song)
+	if err != nil {
+		entry.Error = err.Error()
+		report.Failed++
+		report.Songs = append(report.Songs, entry)
+		return err
+	}
+
+	if err := checkDate(data.ReleaseDate); err != nil {
+		entry.Error = err.Error()
+		report.Failed++
+		report.Songs = append(report.Songs, entry)
+		return nil
+	}
+
+	fresh := song
+	fresh.ReleaseDate, fresh.Text, fresh.Link = data.ReleaseDate, data.Text, data.Link
+	entry.Changes = song.Changes(fresh)
+	if len(entry.Changes) == 0 {
+		return nil
+	}
+
+	if apply {
+		values := make(map[string]string, len(entry.Changes))
+		for _, c := range entry.Changes {
+			values[resyncColumns[c.Field]] = c.New
+		}
+		if err := a.db.UpdateSong(id, values); err != nil {
+			entry.Error = fmt.Sprintf("failed to apply changes: %v", err)
+			report.Failed++
+			report.Songs = append(report.Songs, entry)
+			return nil
+		}
+	}
+
+	report.Changed++
+	report.Songs = append(report.Songs, entry)
+	return nil
+}
+
+// resyncColumns maps the fields a resync compares to the columns they
+// update.
+var resyncColumns = map[string]string{
+	"releaseDate": "releasedate",
+	"text":        "text",
+	"link":        "link",
+}
+
+// checkDate rejects a release date from the info service the stores could
+// not save.
+func checkDate(date string) error {
+	if date == "" {
+		return nil
+	}
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return fmt.Errorf("%w %q from the info service", models.ErrInvalidDate, date)
+	}
+	return nil
+}
+
+// ResyncEvery runs ResyncSongs on every tick of interval until ctx is done.
+func (a *App) ResyncEvery(ctx context.Context, interval time.Duration, batch int, apply bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.ResyncSongs(ctx, batch, apply)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"client"
+	"context"
+	"errors"
+	"testing"
+
+	"musicservice/interal/models"
+)
+
+func TestResyncSongs(t *testing.T) {
+	a, db := newApp(t)
+	id := addSong(t, a, db, "Muse", "Hysteria", "01.12.2003", "It's bugging me")
+	if _, err := a.CreateSong(models.NewSong{Group: "Muse", Song: "Uprising"}); err != nil {
+		t.Fatal(err)
+	}
+	a.providers = []MetadataProvider{stubProvider{detail: client.SongDetail{ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com/new"}}}
+
+	report, err := a.ResyncSongs(context.Background(), 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 1 || report.Changed != 1 || report.Skipped != 1 || len(report.Songs) != 1 {
+		t.Fatalf("got %+v, want one changed song and one still being enriched", report)
+	}
+	if changes := report.Songs[0].Changes; len(changes) != 1 || changes[0].Field != "link" || changes[0].New != "https://example.com/new" {
+		t.Errorf("changes = %+v, want the link", changes)
+	}
+	if song, _ := a.GetSong(id); song.Link != "https://example.com" {
+		t.Errorf("link = %q, want it left alone without apply", song.Link)
+	}
+
+	if _, err := a.ResyncSongs(context.Background(), 1, true); err != nil {
+		t.Fatal(err)
+	}
+	if song, _ := a.GetSong(id); song.Link != "https://example.com/new" {
+		t.Errorf("link = %q, want the change applied", song.Link)
+	}
+	last, err := a.LastResync()
+	if err != nil || !last.Applied {
+		t.Errorf("last resync = %+v, %v, want the applied run", last, err)
+	}
+}
+
+func TestResyncSongsCancelled(t *testing.T) {
+	a, db := newApp(t)
+	addSong(t, a, db, "Muse", "Hysteria", "01.12.2003", "It's bugging me")
+	a.providers = []MetadataProvider{stubProvider{detail: client.SongDetail{Link: "https://example.com/new"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := a.ResyncSongs(ctx, 10, true)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+	if report.Checked != 0 || report.Error == "" {
+		t.Errorf("got %+v, want a run stopped before any song", report)
+	}
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"musicservice/interal/models"
+)
+
+// SongRevisions returns the history of a song, oldest first. A song with
+// no revisions has an empty history; only a missing song is not found.
+func (a *App) SongRevisions(key models.SongKey) ([]models.Revision, error) {
+	log := a.logger.With(
+		slog.String("OP", "SongRevisions"),
+	)
+	log.Info("SongRevisions called with song" + fmt.Sprintf(" %v", key))
+
+	id, err := a.songID(key)
+	if err != nil {
+		log.Debug("Error resolving song" + fmt.Sprintf(" %v", key))
+		return nil, err
+	}
+
+	revisions, err := a.db.Revisions(id)
+	if err != nil {
+		log.Error("Error getting revisions" + fmt.Sprintf(" %d", id))
+		return nil, fmt.Errorf("failed to get revisions: %w", err)
+	}
+
+	log.Info("SongRevisions complete with" + fmt.Sprintf(" %d revisions", len(revisions)))
+	return revisions, nil
+}
+
+// RevisionDiff compares the snapshots of two revisions of a song.
+func (a *App) RevisionDiff(songID, from, to uint64) (models.RevisionDiff, error) {
+	log := a.logger.With(
+		slog.String("OP", "RevisionDiff"),
+	)
+	log.Info("RevisionDiff called with song" + fmt.Sprintf(" %d from %d to %d", songID, from, to))
+
+	fromRev, err := a.db.Revision(songID, from)
+	if err != nil {
+		log.Debug("Error getting revision" + fmt.Sprintf(" %d", from))
+		return models.RevisionDiff{}, fmt.Errorf("failed to get revision %d: %w", from, err)
+	}
+
+	toRev, err := a.db.Revision(songID, to)
+	if err != nil {
+		log.Debug("Error getting revision" + fmt.Sprintf(" %d", to))
+		return models.RevisionDiff{}, fmt.Errorf("failed to get revision %d: %w", to, err)
+	}
+
+	return models.RevisionDiff{
+		SongID:  songID,
+		From:    from,
+		To:      to,
+		Changes: fromRev.Snapshot.Changes(toRev.Snapshot),
+	}, nil
+}
+
+// RollbackSong restores a song to the state recorded by one of its
+// revisions.
+func (a *App) RollbackSong(songID, revisionID uint64) error {
+	log := a.logger.With(
+		slog.String("OP", "RollbackSong"),
+	)
+	log.Info("RollbackSong called with song" + fmt.Sprintf(" %d revision %d", songID, revisionID))
+
+	err := a.db.RollbackSong(songID, revisionID)
+	if err != nil {
+		log.Debug("Error rolling back song" + fmt.Sprintf(" %d", songID))
+		return fmt.Errorf("failed to roll back song: %w", err)
+	}
+
+	log.Info("Song rolled back" + fmt.Sprintf(" %d to revision %d", songID, revisionID))
+	return nil
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"musicservice/interal/models"
+)
+
+func TestSongRevisions(t *testing.T) {
+	a, db := newApp(t)
+	id := addSong(t, a, db, "Muse", "Hysteria", "01.12.2003", "It's bugging me")
+
+	revisions, err := a.SongRevisions(models.SongKey{ID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Action != models.ActionCreate {
+		t.Errorf("got %+v, want the creation and the enrichment", revisions)
+	}
+
+	for _, key := range []models.SongKey{{ID: id + 1}, {Group: "Muse", Song: "Uprising"}} {
+		if _, err := a.SongRevisions(key); !errors.Is(err, models.ErrSongNotFound) {
+			t.Errorf("SongRevisions(%+v) err = %v, want %v", key, err, models.ErrSongNotFound)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"musicservice/interal/models"
+)
+
+// ErrInvalidSort is returned for a sort songs cannot be ordered by.
+var ErrInvalidSort = models.Invalid("invalid_sort", "invalid sort")
+
+var sortFields = map[string]bool{
+	models.SortReleaseDate: true,
+	models.SortGroup:       true,
+	models.SortSong:        true,
+	models.SortID:          true,
+	models.SortRelevance:   true,
+}
+
+// parseSort reads a comma-separated list of sort fields, each ascending
+// unless prefixed with "-", like "-releaseDate,group". Songs that tie on
+// every key stay ordered by id, so an empty sort keeps the default order.
+func parseSort(sort string) ([]models.SortKey, error) {
+	if sort == "" {
+		return nil, nil
+	}
+
+	var keys []models.SortKey
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		key := models.SortKey{Field: strings.TrimLeft(field, "+-"), Desc: strings.HasPrefix(field, "-")}
+		if len(field)-len(key.Field) > 1 || !sortFields[key.Field] {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSort, field)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("%w: field %q given twice", ErrInvalidSort, key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// keyset reports whether songs sorted by keys are in ascending id order, so
+// pages can continue after the last id seen instead of at an offset.
+func keyset(keys []models.SortKey) bool {
+	return len(keys) > 0 && keys[0] == models.SortKey{Field: models.SortID}
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"musicservice/interal/models"
+)
+
+// TrashedSongs returns one page of the songs in the trash.
+func (a *App) TrashedSongs(page models.Page) (models.TrashPage, error) {
+	log := a.logger.With(
+		slog.String("OP", "TrashedSongs"),
+	)
+	log.Info("TrashedSongs called with page" + fmt.Sprintf(" %v", page))
+
+	page.Limit = pageLimit(page.Limit)
+
+	songs, total, err := a.db.TrashedSongs(page)
+	if err != nil {
+		log.Error("Error getting trashed songs " + err.Error())
+		return models.TrashPage{}, fmt.Errorf("failed to get trashed songs: %w", err)
+	}
+
+	log.Info("TrashedSongs complete with" + fmt.Sprintf(" %d songs", len(songs)))
+	return models.TrashPage{Songs: songs, Total: total}, nil
+}
+
+// RestoreSong takes a song out of the trash.
+func (a *App) RestoreSong(id uint64) error {
+	log := a.logger.With(
+		slog.String("OP", "RestoreSong"),
+	)
+	log.Info("RestoreSong called with song" + fmt.Sprintf(" %d", id))
+
+	err := a.db.RestoreSong(id)
+	if err != nil {
+		log.Debug("Error restoring song" + fmt.Sprintf(" %d", id))
+		return fmt.Errorf("failed to restore song: %w", err)
+	}
+
+	log.Info("Song restored" + fmt.Sprintf(" %d", id))
+	return nil
+}
+
+// PurgeTrash permanently removes the songs that have been in the trash for
+// longer than the retention period.
+func (a *App) PurgeTrash() (int64, error) {
+	log := a.logger.With(
+		slog.String("OP", "PurgeTrash"),
+	)
+
+	n, err := a.db.PurgeSongs(time.Now().Add(-a.retention))
+	if err != nil {
+		log.Error("Error purging trash " + err.Error())
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	log.Info("Trash purged" + fmt.Sprintf(" %d songs", n))
+	return n, nil
+}
+
+// PurgeTrashEvery runs PurgeTrash on every tick of interval, which must be
+// positive, until ctx is done. A purge under way when ctx is done finishes
+// before it returns.
+func (a *App) PurgeTrashEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.PurgeTrash()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"musicservice/interal/models"
+)
+
+func TestPurgeTrashEvery(t *testing.T) {
+	a, db := newApp(t)
+	id := addSong(t, a, db, "Muse", "Hysteria", "01.12.2003", "It's bugging me")
+	if err := a.DeleteSong(models.SongKey{ID: id}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.PurgeTrashEvery(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		trash, err := a.TrashedSongs(models.Page{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trash.Total == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("trash was not purged")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("PurgeTrashEvery did not return")
+	}
+}
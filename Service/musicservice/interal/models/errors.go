@@ -0,0 +1,70 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of domain errors. Every Error wraps one of them, so callers can tell
+// a missing song from a broken database with errors.Is.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrUpstream   = errors.New("upstream failure")
+)
+
+// Domain errors the stores and the App share.
+var (
+	ErrSongNotFound        = NotFound("song_not_found", "song not found")
+	ErrGroupNotFound       = NotFound("group_not_found", "group not found")
+	ErrRevisionNotFound    = NotFound("revision_not_found", "revision not found")
+	ErrSongExists          = Conflict("song_exists", "song already exists")
+	ErrGroupExists         = Conflict("group_exists", "group already exists")
+	ErrGroupNotEmpty       = Conflict("group_not_empty", "group still has songs")
+	ErrEnrichmentNotFailed = Conflict("enrichment_not_failed", "only a failed enrichment can be retried")
+	ErrNothingToUpdate     = Invalid("nothing_to_update", "nothing to update")
+	ErrInvalidDate         = Invalid("invalid_release_date", "invalid release date")
+)
+
+// Error is a domain error with a stable machine-readable code. Errors with
+// the same code match each other with errors.Is, so a sentinel still matches
+// after it was wrapped with more details.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// NotFound returns an error of kind ErrNotFound.
+func NotFound(code, format string, args ...any) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflict returns an error of kind ErrConflict.
+func Conflict(code, format string, args ...any) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Invalid returns an error of kind ErrValidation.
+func Invalid(code, format string, args ...any) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Upstream returns an error of kind ErrUpstream.
+func Upstream(code, format string, args ...any) *Error {
+	return &Error{Kind: ErrUpstream, Code: code, Message: fmt.Sprintf(format, args...)}
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"strings"
+	"time"
+)
+
 // Song model info
 // @Description Song information about the account
 type Song struct {
@@ -9,6 +14,23 @@ type Song struct {
 	ReleaseDate string `json:"releaseDate"`
 	Text string `json:"text"`
 	Link string `json:"link"`
+	Language string `json:"language"`
+	Rank float64 `json:"rank,omitempty"`
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// Trashed song model info
+// @Description Song moved to the trash and the time it was deleted
+type TrashedSong struct {
+	Song
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// Trash page model info
+// @Description Page of trashed songs with the total count
+type TrashPage struct {
+	Songs []TrashedSong `json:"songs"`
+	Total int `json:"total"`
 }
 
 // Filter song model info
@@ -17,14 +39,303 @@ type FilterSong struct {
 	Group string `json:"group"` 
 	Song string `json:"song"`
 	ReleaseDate string `json:"releaseDate"`
+	ReleasedFrom string `json:"releasedFrom" example:"01.01.2000"`
+	ReleasedTo string `json:"releasedTo" example:"31.12.2009"`
+	Year int `json:"year,omitempty" example:"2006"`
+	Decade int `json:"decade,omitempty" example:"1990"`
 	Text string `json:"text"`
 	Link string `json:"link"` 
+	Language string `json:"language"`
+	TextLanguage string `json:"textLanguage"`
+	Match string `json:"match" enums:"exact,fuzzy"`
+	Threshold float64 `json:"threshold,omitempty"`
 }
 
+// Match modes of FilterSong. A fuzzy match compares group and song by
+// trigram similarity, ignoring case and accents.
+const (
+	MatchExact = "exact"
+	MatchFuzzy = "fuzzy"
+)
+
 // New song model info
 // @Description Song information about user
 type NewSong struct {
 	Group string `json:"group"`
 	Song string `json:"song"`
+	Language string `json:"language,omitempty"`
+}
+
+// Song patch model info
+// @Description JSON merge patch of a song (RFC 7396): a field left out stays as it is and a null field is cleared; group and song can be changed but not cleared, and a cleared language goes back to the default
+type SongPatch map[string]*string
+
+// Enrichment statuses of a song. A song is created pending and gets its
+// details from the info service in the background.
+const (
+	EnrichmentPending = "pending"
+	EnrichmentRunning = "running"
+	EnrichmentDone = "done"
+	EnrichmentFailed = "failed"
+)
+
+// Enrichment model info
+// @Description Progress of fetching a song's release date, lyrics and link from the info service; a failed enrichment can be retried
+type Enrichment struct {
+	SongID uint64 `json:"songId"`
+	Status string `json:"status" enums:"pending,running,done,failed"`
+	Attempts int `json:"attempts"`
+	LastError string `json:"lastError,omitempty"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// EnrichmentJob is a song claimed for enrichment, with the number of the
+// attempt the claim started. DetectLanguage is set when the song was created
+// without a language, which is then detected from the lyrics
+type EnrichmentJob struct {
+	SongID uint64
+	Group string
+	Song string
+	Attempt int
+	DetectLanguage bool
+}
+
+// Resync report model info
+// @Description Summary of comparing the details of every song with the info service; with Applied unset the changes were only recorded for review
+type ResyncReport struct {
+	StartedAt time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Applied bool `json:"applied"`
+	Checked int `json:"checked"`
+	Changed int `json:"changed"`
+	Skipped int `json:"skipped"`
+	Failed int `json:"failed"`
+	Error string `json:"error,omitempty"`
+	Songs []ResyncSong `json:"songs"`
+}
+
+// Resync song model info
+// @Description Song whose details differ from the info service, with the differing fields, or that could not be checked
+type ResyncSong struct {
+	SongID uint64 `json:"songId"`
+	Group string `json:"group"`
+	Song string `json:"song"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Import statuses of the rows of a bulk import
+const (
+	ImportCreated = "created"
+	ImportDuplicate = "duplicate"
+	ImportFailed = "failed"
+)
+
+// Import row model info
+// @Description Outcome of one row of a bulk import; Row is the line or record number in the uploaded file
+type ImportRow struct {
+	Row int `json:"row"`
+	Group string `json:"group"`
+	Song string `json:"song"`
+	Status string `json:"status" enums:"created,duplicate,failed"`
+	ID uint64 `json:"id,omitempty"`
+	Code string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Import report model info
+// @Description Per-row report of a bulk import with the count of each outcome
+type ImportReport struct {
+	Created int `json:"created"`
+	Duplicates int `json:"duplicates"`
+	Failed int `json:"failed"`
+	Rows []ImportRow `json:"rows"`
+}
+
+// SongKey identifies a song by its ID or, when ID is zero, by its title
+// within Group; an empty Group matches the title in any group
+type SongKey struct {
+	ID uint64
+	Group string
+	Song string
+}
+
+// Page is the window of search results to return: songs after the keyset
+// cursor After, skipping Offset of them, at most Limit songs, in the order
+// of the Order keys. Cursor and Sort are the opaque cursor and the sort a
+// client sent, which the App decodes into After or Offset and into Order
+type Page struct {
+	Limit int
+	Offset int
+	After uint64
+	Cursor string
+	Sort string
+	Order []SortKey
+}
+
+// SortKey orders songs by one field, ascending unless Desc is set
+type SortKey struct {
+	Field string
+	Desc bool
+}
+
+// Fields songs can be sorted by. Relevance is the rank of a song for the
+// text filter; songs without a release date come last in either direction.
+const (
+	SortReleaseDate = "releaseDate"
+	SortGroup = "group"
+	SortSong = "song"
+	SortID = "id"
+	SortRelevance = "relevance"
+)
+
+// Songs page model info
+// @Description Page of songs with the total count and the cursor of the next page
+type SongsPage struct {
+	Songs []Song `json:"songs"`
+	Total int `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Group model info
+// @Description Group with the number of its songs outside the trash
+type Group struct {
+	Name string `json:"name"`
+	SongCount int `json:"songCount"`
+}
+
+// Groups page model info
+// @Description Page of groups with the total count
+type GroupsPage struct {
+	Groups []Group `json:"groups"`
+	Total int `json:"total"`
+}
+
+// Group page model info
+// @Description Group with one page of its songs
+type GroupPage struct {
+	Group
+	Songs []Song `json:"songs"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Verse model info
+// @Description Verse of the lyrics, numbered from 1 across the whole song
+type Verse struct {
+	Index int `json:"index"`
+	Lines []string `json:"lines"`
+}
+
+// Lyrics model info
+// @Description Page of the verses of a song's lyrics with pagination metadata
+type Lyrics struct {
+	SongID uint64 `json:"songId"`
+	Verses []Verse `json:"verses"`
+	Page int `json:"page"`
+	PageSize int `json:"pageSize"`
+	TotalVerses int `json:"totalVerses"`
+	HasNext bool `json:"hasNext"`
+}
+
+// Text renders the verses as plain text, lines separated by newlines and
+// verses by blank lines.
+func (l Lyrics) Text() string {
+	verses := make([]string, 0, len(l.Verses))
+	for _, v := range l.Verses {
+		verses = append(verses, strings.Join(v.Lines, "\n"))
+	}
+	return strings.Join(verses, "\n\n")
+}
+
+// Lyrics search model info
+// @Description Verses of a song's lyrics that contain a phrase, in order
+type LyricsSearch struct {
+	SongID uint64 `json:"songId"`
+	Query string `json:"query"`
+	Verses []VerseMatch `json:"verses"`
+	TotalMatches int `json:"totalMatches"`
 }
 
+// Verse match model info
+// @Description Verse with every line of it and the lines that contain the phrase
+type VerseMatch struct {
+	Verse
+	Matches []LineMatch `json:"matches"`
+}
+
+// Line match model info
+// @Description Line containing the phrase, numbered from 1 within its verse, with the character offsets of each occurrence and the line, HTML-escaped, with the occurrences wrapped in <mark> tags
+type LineMatch struct {
+	Line int `json:"line"`
+	Text string `json:"text"`
+	Spans []Span `json:"spans"`
+	Highlight string `json:"highlight"`
+}
+
+// Span model info
+// @Description Occurrence of the phrase from character Start up to, not including, character End of a line, counted in Unicode code points
+type Span struct {
+	Start int `json:"start"`
+	End int `json:"end"`
+}
+
+// Revision actions
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionRestore = "restore"
+	ActionRollback = "rollback"
+)
+
+// Revision model info
+// @Description Song state after a change with the fields the change touched
+type Revision struct {
+	ID uint64 `json:"id"`
+	SongID uint64 `json:"songId"`
+	Action string `json:"action"`
+	Snapshot Song `json:"snapshot"`
+	Changes []FieldChange `json:"changes"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Revision diff model info
+// @Description Fields that differ between two revisions of a song
+type RevisionDiff struct {
+	SongID uint64 `json:"songId"`
+	From uint64 `json:"from"`
+	To uint64 `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// Field change model info
+// @Description Field value before and after a change
+type FieldChange struct {
+	Field string `json:"field"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Changes lists the fields whose values differ between s and to.
+func (s Song) Changes(to Song) []FieldChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"group", s.Group, to.Group},
+		{"song", s.Song, to.Song},
+		{"releaseDate", s.ReleaseDate, to.ReleaseDate},
+		{"text", s.Text, to.Text},
+		{"link", s.Link, to.Link},
+		{"language", s.Language, to.Language},
+	}
+
+	changes := make([]FieldChange, 0, len(fields))
+	for _, f := range fields {
+		if f.old != f.new {
+			changes = append(changes, FieldChange{Field: f.name, Old: f.old, New: f.new})
+		}
+	}
+	return changes
+}
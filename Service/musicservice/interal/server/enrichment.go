@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// SongEnrichment godoc
+// @Summary      Get song enrichment
+// @Description  get how far fetching a song's details from the info service has got: pending or running while it is queued or being fetched, done once the details are saved and failed when the info service has no details or the attempts ran out
+// @Tags         songs
+// @Produce      json
+// @Param        id path int true "song id"
+// @Success      200  {object} models.Enrichment
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/{id}/enrichment [get]
+func (s *MysicServer) SongEnrichment(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting song enrichment from server " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	status, err := s.app.Enrichment(id)
+	if err != nil {
+		s.logger.Debug("Error getting enrichment from database " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+	s.logger.Info("Song enrichment returned to server " + r.URL.String())
+}
+
+// RetryEnrichment godoc
+// @Summary      Retry song enrichment
+// @Description  queue a song whose enrichment failed again, with a fresh count of attempts
+// @Tags         songs
+// @Param        id path int true "song id"
+// @Success      202 "enrichment queued"
+// @Header       202 {string} Location "path of the enrichment status"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      409 {object} server.Problem "Enrichment has not failed"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/{id}/enrichment/retry [post]
+func (s *MysicServer) RetryEnrichment(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Retrying song enrichment from server " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	err = s.app.RetryEnrichment(id)
+	if err != nil {
+		s.logger.Debug("Error retrying enrichment " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/songs/"+r.PathValue("id")+"/enrichment")
+	w.WriteHeader(http.StatusAccepted)
+	s.logger.Info("Song enrichment queued from server " + r.URL.String())
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"musicservice/interal/models"
+)
+
+// Formats the catalog can be exported in.
+const (
+	exportNDJSON = "ndjson"
+	exportCSV    = "csv"
+	exportJSON   = "json"
+)
+
+// exportFlushEvery is how many songs are written between flushes, so a
+// client sees the export progress without a flush per row.
+const exportFlushEvery = 100
+
+var exportTypes = map[string]string{
+	exportNDJSON: "application/x-ndjson",
+	exportCSV:    "text/csv; charset=utf-8",
+	exportJSON:   "application/json",
+}
+
+// ExportSongs godoc
+// @Summary      Export songs
+// @Description  stream every song matching the filter query parameters in id order as NDJSON, CSV or a JSON array, optionally with lyrics; songs are read from a database cursor and written as they come, so the response has no fixed length
+// @Tags         songs
+// @Produce      json
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format query string false "export format, ndjson when empty" Enums(ndjson, csv, json)
+// @Param        lyrics query bool false "include the lyrics of each song"
+// @Param        group query string false "group name"
+// @Param        song query string false "song name"
+// @Param        releaseDate query string false "exact release date, DD.MM.YYYY"
+// @Param        releasedFrom query string false "released on or after, DD.MM.YYYY"
+// @Param        releasedTo query string false "released on or before, DD.MM.YYYY"
+// @Param        year query int false "release year"
+// @Param        decade query int false "release decade, like 1990"
+// @Param        text query string false "words of the lyrics"
+// @Param        textLanguage query string false "language of text, detected when empty or auto"
+// @Param        link query string false "song link"
+// @Param        language query string false "song language"
+// @Param        match query string false "exact or fuzzy matching of group and song" Enums(exact, fuzzy)
+// @Param        threshold query number false "similarity a fuzzy match needs"
+// @Success      200  {array} models.Song
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/export [get]
+func (s *MysicServer) ExportSongs(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Exporting songs from server " + r.URL.String())
+
+	filter, err := filterQuery(r)
+	if err != nil {
+		s.logger.Debug("Error parsing filter " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportNDJSON
+	}
+	if _, ok := exportTypes[format]; !ok {
+		fail(w, r, models.Invalid(codeInvalidParameter, "invalid format %q", format))
+		return
+	}
+
+	withText := false
+	if value := r.URL.Query().Get("lyrics"); value != "" {
+		withText, err = strconv.ParseBool(value)
+		if err != nil {
+			fail(w, r, models.Invalid(codeInvalidParameter, "invalid lyrics %q", value))
+			return
+		}
+	}
+
+	export := newExporter(w, format, withText)
+	err = s.app.ExportSongs(filter, withText, export.write)
+	if err != nil && !export.started {
+		s.logger.Debug("Error exporting songs " + err.Error())
+		fail(w, r, err)
+		return
+	}
+	if err != nil {
+		// The status is already sent; all that is left is to cut the
+		// stream short and tell the log why.
+		s.logger.Error("Export interrupted after " + strconv.Itoa(export.rows) + " songs " + err.Error())
+		return
+	}
+	if err := export.close(); err != nil {
+		s.logger.Error("Error finishing export " + err.Error())
+		return
+	}
+	s.logger.Info("Songs exported from server " + r.URL.String())
+}
+
+// exporter writes songs in one export format. Headers are only sent with
+// the first song, so an export failing before it can still answer with a
+// problem.
+type exporter struct {
+	w        http.ResponseWriter
+	format   string
+	withText bool
+	started  bool
+	rows     int
+	csv      *csv.Writer
+	json     *json.Encoder
+}
+
+func newExporter(w http.ResponseWriter, format string, withText bool) *exporter {
+	return &exporter{w: w, format: format, withText: withText}
+}
+
+func (e *exporter) start() error {
+	e.started = true
+	header := e.w.Header()
+	header.Set("Content-Type", exportTypes[e.format])
+	header.Set("Content-Disposition", `attachment; filename="songs.`+e.format+`"`)
+	e.w.WriteHeader(http.StatusOK)
+
+	switch e.format {
+	case exportCSV:
+		e.csv = csv.NewWriter(e.w)
+		return e.csv.Write(e.csvHeader())
+	case exportJSON:
+		e.json = json.NewEncoder(e.w)
+		_, err := io.WriteString(e.w, "[")
+		return err
+	default:
+		e.json = json.NewEncoder(e.w)
+	}
+	return nil
+}
+
+func (e *exporter) write(song models.Song) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch e.format {
+	case exportCSV:
+		err = e.csv.Write(e.csvRecord(song))
+	case exportJSON:
+		if e.rows > 0 {
+			if _, err := io.WriteString(e.w, ","); err != nil {
+				return err
+			}
+		}
+		err = e.json.Encode(song)
+	default:
+		err = e.json.Encode(song)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushEvery == 0 {
+		e.flush()
+	}
+	return nil
+}
+
+// close ends the export, answering with an empty one when no song matched.
+func (e *exporter) close() error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	if e.format == exportJSON {
+		if _, err := io.WriteString(e.w, "]\n"); err != nil {
+			return err
+		}
+	}
+	e.flush()
+	if e.csv != nil {
+		return e.csv.Error()
+	}
+	return nil
+}
+
+func (e *exporter) flush() {
+	if e.csv != nil {
+		e.csv.Flush()
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (e *exporter) csvHeader() []string {
+	header := []string{"id", "group", "song", "releaseDate", "link", "language"}
+	if e.withText {
+		header = append(header, "text")
+	}
+	return header
+}
+
+func (e *exporter) csvRecord(song models.Song) []string {
+	record := []string{song.ID, song.Group, song.Song, song.ReleaseDate, song.Link, song.Language}
+	if e.withText {
+		record = append(record, song.Text)
+	}
+	return record
+}
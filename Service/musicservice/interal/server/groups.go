@@ -0,0 +1,189 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"musicservice/interal/app"
+	"musicservice/interal/models"
+)
+
+// Group name is the new name of a group
+// @Description New name of a group
+type GroupName struct {
+	Name string `json:"name"`
+}
+
+// Merge into is the group another group is merged into
+// @Description Group to merge into
+type MergeInto struct {
+	Into string `json:"into"`
+}
+
+// ListGroups godoc
+// @Summary      List groups
+// @Description  list groups by name with the number of their songs outside the trash
+// @Tags         groups
+// @Produce      json
+// @Param        size query int false "groups per page"
+// @Param        offset query int false "groups to skip"
+// @Success      200 {object} models.GroupsPage
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /groups [get]
+func (s *MysicServer) ListGroups(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Listing groups from server " + r.URL.String())
+
+	var page models.Page
+	var err error
+
+	page.Limit, err = queryInt(r, "size", app.DefaultPageSize)
+	if err != nil || page.Limit < 1 {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid size")
+		return
+	}
+
+	page.Offset, err = queryInt(r, "offset", 0)
+	if err != nil || page.Offset < 0 {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid offset")
+		return
+	}
+
+	groups, err := s.app.Groups(page)
+	if err != nil {
+		s.logger.Error("Error getting groups from database " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+	s.logger.Info("Groups returned to server " + r.URL.String())
+}
+
+// GetGroup godoc
+// @Summary      Get group
+// @Description  get a group with one page of its songs
+// @Tags         groups
+// @Produce      json
+// @Param        name path string true "group name"
+// @Param        size query int false "songs per page"
+// @Param        cursor query string false "next cursor of the previous page"
+// @Success      200 {object} models.GroupPage
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Router       /groups/{name} [get]
+func (s *MysicServer) GetGroup(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting group from database " + r.URL.String())
+
+	var page models.Page
+	var err error
+
+	page.Limit, err = queryInt(r, "size", app.DefaultPageSize)
+	if err != nil || page.Limit < 1 {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid size")
+		return
+	}
+	page.Cursor = r.URL.Query().Get("cursor")
+
+	group, err := s.app.GetGroup(r.PathValue("name"), page)
+	if err != nil {
+		s.logger.Debug("Error getting group from database " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+	s.logger.Info("Group returned to server " + r.URL.String())
+}
+
+// RenameGroup godoc
+// @Summary      Rename group
+// @Description  rename a group and every song of it; the new name must not be taken
+// @Tags         groups
+// @Accept       json
+// @Param        name path string true "group name"
+// @Param        input body server.GroupName true "new name"
+// @Success      204 "success response"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      409 {object} server.Problem "Conflict error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /groups/{name} [patch]
+func (s *MysicServer) RenameGroup(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Renaming group in database " + r.URL.String())
+
+	var body GroupName
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		s.logger.Debug("Error decoding group name " + err.Error())
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidBody, "invalid request body")
+		return
+	}
+
+	err = s.app.RenameGroup(r.PathValue("name"), body.Name)
+	s.groupChanged(w, r, err)
+}
+
+// MergeGroups godoc
+// @Summary      Merge groups
+// @Description  move the songs of a group into another group and remove it; fails when both have a song with the same title
+// @Tags         groups
+// @Accept       json
+// @Param        name path string true "group to merge"
+// @Param        input body server.MergeInto true "group to merge into"
+// @Success      204 "success response"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      409 {object} server.Problem "Conflict error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /groups/{name}/merge [post]
+func (s *MysicServer) MergeGroups(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Merging groups in database " + r.URL.String())
+
+	var body MergeInto
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		s.logger.Debug("Error decoding merge target " + err.Error())
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidBody, "invalid request body")
+		return
+	}
+
+	err = s.app.MergeGroups(r.PathValue("name"), body.Into)
+	s.groupChanged(w, r, err)
+}
+
+// DeleteGroup godoc
+// @Summary      Delete group
+// @Description  remove a group that has no songs, in the trash or not
+// @Tags         groups
+// @Param        name path string true "group name"
+// @Success      204 "success response"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      409 {object} server.Problem "Conflict error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /groups/{name} [delete]
+func (s *MysicServer) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Deleting group from database " + r.URL.String())
+
+	err := s.app.DeleteGroup(r.PathValue("name"))
+	s.groupChanged(w, r, err)
+}
+
+// groupChanged answers a request that changed a group.
+func (s *MysicServer) groupChanged(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		s.logger.Error("Error changing group in database " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	s.logger.Info("Group changed in server " + r.URL.String())
+}
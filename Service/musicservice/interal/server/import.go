@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"musicservice/interal/app"
+)
+
+// maxImportBytes bounds the size of an uploaded import file.
+const maxImportBytes = 10 << 20
+
+// ImportSongs godoc
+// @Summary      Import songs
+// @Description  create many songs from a CSV file of group, song and optional language columns, or from NDJSON lines of new songs; every row is reported as created, duplicate or failed, and created songs are queued for enrichment
+// @Tags         songs
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Param        format query string false "format of the body, taken from Content-Type when empty" Enums(csv, ndjson)
+// @Param        input body string true "CSV or NDJSON rows"
+// @Success      200  {object} models.ImportReport
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      405 "Method not allowed"
+// @Failure      413  {object} server.Problem "Request too large"
+// @Failure      415  {object} server.Problem "Unsupported format"
+// @Router       /songs/import [post]
+func (s *MysicServer) ImportSongs(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Importing songs to database " + r.URL.String())
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+	if format != app.ImportCSV && format != app.ImportNDJSON {
+		s.logger.Debug("Unsupported import format " + format)
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "unsupported_format", "send text/csv or application/x-ndjson, or set format to csv or ndjson")
+		return
+	}
+
+	entries, err := app.ParseImport(http.MaxBytesReader(w, r.Body, maxImportBytes), format)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeProblem(w, r, http.StatusRequestEntityTooLarge, "import_too_large", "import files are limited to 10 MiB")
+		return
+	}
+	if err != nil {
+		s.logger.Debug("Error parsing import " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	report := s.app.ImportSongs(entries)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+	s.logger.Info("Songs imported to server " + r.URL.String())
+}
+
+// importFormat names the import format of a Content-Type.
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return app.ImportCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return app.ImportNDJSON
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// InfoCacheStats godoc
+// @Summary      Get info cache stats
+// @Description  get the hits, misses, evictions and hit rate of the cache in front of the song info service; hits include songs the service does not know and callers that waited for a call already being made
+// @Tags         info cache
+// @Produce      json
+// @Success      200  {object} infocache.Stats
+// @Failure      404 {object} server.Problem "Info cache is disabled"
+// @Failure      405 "Method not allowed"
+// @Router       /info-cache [get]
+func (s *MysicServer) InfoCacheStats(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting info cache stats from server " + r.URL.String())
+
+	stats, err := s.app.InfoCacheStats()
+	if err != nil {
+		s.logger.Debug("Error getting info cache stats " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+	s.logger.Info("Info cache stats returned to server " + r.URL.String())
+}
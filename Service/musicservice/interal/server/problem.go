@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"musicservice/interal/models"
+)
+
+// Problem model info
+// @Description Error response in the RFC 7807 problem details format with a stable error code
+type Problem struct {
+	Type     string `json:"type" example:"about:blank"`
+	Title    string `json:"title" example:"Not Found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail,omitempty" example:"failed to get song: song not found"`
+	Instance string `json:"instance,omitempty" example:"/songs/42"`
+	Code     string `json:"code" example:"song_not_found"`
+}
+
+// Codes of the problems the server reports without asking the App.
+const (
+	codeInvalidBody      = "invalid_body"
+	codeInvalidParameter = "invalid_parameter"
+	codeInternal         = "internal_error"
+)
+
+// kindStatus maps the kinds of domain errors to the status they answer with.
+var kindStatus = []struct {
+	kind   error
+	status int
+}{
+	{models.ErrNotFound, http.StatusNotFound},
+	{models.ErrConflict, http.StatusConflict},
+	{models.ErrValidation, http.StatusBadRequest},
+	{models.ErrUpstream, http.StatusBadGateway},
+}
+
+// fail writes the problem err describes. Domain errors answer with the
+// status of their kind and their code; anything else is an internal error
+// whose details are left to the caller's log.
+func fail(w http.ResponseWriter, r *http.Request, err error) {
+	var domain *models.Error
+	if errors.As(err, &domain) {
+		for _, k := range kindStatus {
+			if errors.Is(domain.Kind, k.kind) {
+				writeProblem(w, r, k.status, domain.Code, err.Error())
+				return
+			}
+		}
+	}
+
+	writeProblem(w, r, http.StatusInternalServerError, codeInternal, "the server failed to handle the request")
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	})
+}
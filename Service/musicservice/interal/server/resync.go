@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"musicservice/interal/app"
+	"musicservice/interal/models"
+)
+
+// LastResync godoc
+// @Summary      Get last resync
+// @Description  get the report of the last resync of song details with the info service, scheduled or not
+// @Tags         resync
+// @Produce      json
+// @Success      200  {object} models.ResyncReport
+// @Failure      404 {object} server.Problem "No resync has run yet"
+// @Failure      405 "Method not allowed"
+// @Router       /resync [get]
+func (s *MysicServer) LastResync(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting last resync from server " + r.URL.String())
+
+	report, err := s.app.LastResync()
+	if err != nil {
+		s.logger.Debug("Error getting last resync " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+	s.logger.Info("Last resync returned to server " + r.URL.String())
+}
+
+// Resync godoc
+// @Summary      Resync songs
+// @Description  compare the link, release date and lyrics of every song with the info service and report the songs that differ; the differences are applied when apply is set and only recorded for review otherwise. Songs still being enriched are skipped and a run that stops early, when the info service is unavailable, the songs cannot be read or the request is cancelled, reports why in error
+// @Tags         resync
+// @Produce      json
+// @Param        apply query bool false "apply the changes instead of only reporting them"
+// @Param        batch query int false "songs read at a time"
+// @Success      200  {object} models.ResyncReport
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      409 {object} server.Problem "A resync is already running"
+// @Failure      405 "Method not allowed"
+// @Router       /resync [post]
+func (s *MysicServer) Resync(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Resyncing songs from server " + r.URL.String())
+
+	apply := false
+	if value := r.URL.Query().Get("apply"); value != "" {
+		var err error
+		apply, err = strconv.ParseBool(value)
+		if err != nil {
+			fail(w, r, models.Invalid(codeInvalidParameter, "invalid apply %q", value))
+			return
+		}
+	}
+	batch, err := queryInt(r, "batch", app.DefaultResyncBatch)
+	if err != nil || batch < 1 {
+		fail(w, r, models.Invalid(codeInvalidParameter, "invalid batch %q", r.URL.Query().Get("batch")))
+		return
+	}
+
+	report, err := s.app.ResyncSongs(r.Context(), batch, apply)
+	if errors.Is(err, app.ErrResyncRunning) {
+		s.logger.Debug("Error resyncing songs " + err.Error())
+		fail(w, r, err)
+		return
+	}
+	if err != nil {
+		s.logger.Error("Resync stopped early " + err.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+	s.logger.Info("Songs resynced from server " + r.URL.String())
+}
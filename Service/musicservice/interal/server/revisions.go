@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+
+	"musicservice/interal/models"
+)
+
+// ListSongRevisions godoc
+// @Summary      Song revisions
+// @Description  list the revisions of a song, oldest first
+// @Tags         revisions
+// @Produce      json
+// @Param        id path int true "song id"
+// @Success      200 {array} models.Revision
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/{id}/revisions [get]
+func (s *MysicServer) ListSongRevisions(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting song revisions from database " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	s.songRevisions(w, r, models.SongKey{ID: id})
+}
+
+// DiffSongRevisions godoc
+// @Summary      Revision diff
+// @Description  compare two revisions of a song
+// @Tags         revisions
+// @Produce      json
+// @Param        id path int true "song id"
+// @Param        from query int true "revision id to compare from"
+// @Param        to query int true "revision id to compare to"
+// @Success      200 {object} models.RevisionDiff
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/{id}/revisions/diff [get]
+func (s *MysicServer) DiffSongRevisions(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting revision diff from database " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	s.revisionDiff(w, r, id)
+}
+
+// RevertSong godoc
+// @Summary      Roll back song
+// @Description  restore the fields of a song to one of its revisions
+// @Tags         revisions
+// @Param        id path int true "song id"
+// @Param        revision query int true "revision id"
+// @Success      204 "success response"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/{id}/rollback [post]
+func (s *MysicServer) RevertSong(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Rolling back song in database " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	s.rollbackSong(w, r, id)
+}
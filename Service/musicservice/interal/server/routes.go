@@ -0,0 +1,60 @@
+package server
+
+import "net/http"
+
+// Routes registers the endpoints of the server on a new mux. Songs are
+// served as resources under /songs; the verb-style paths they replace stay
+// as deprecated aliases that point clients at their successors.
+func (s *MysicServer) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /songs", s.ListSongs)
+	mux.HandleFunc("POST /songs", s.PostSong)
+	mux.HandleFunc("POST /songs/import", s.ImportSongs)
+	mux.HandleFunc("GET /songs/export", s.ExportSongs)
+	mux.HandleFunc("GET /songs/{id}", s.GetSong)
+	mux.HandleFunc("PATCH /songs/{id}", s.PatchSong)
+	mux.HandleFunc("DELETE /songs/{id}", s.RemoveSong)
+	mux.HandleFunc("GET /songs/{id}/text", s.GetSongText)
+	mux.HandleFunc("GET /songs/{id}/text/search", s.SearchSongText)
+	mux.HandleFunc("GET /songs/{id}/enrichment", s.SongEnrichment)
+	mux.HandleFunc("POST /songs/{id}/enrichment/retry", s.RetryEnrichment)
+	mux.HandleFunc("POST /songs/{id}/restore", s.RestoreTrashedSong)
+	mux.HandleFunc("GET /songs/{id}/revisions", s.ListSongRevisions)
+	mux.HandleFunc("GET /songs/{id}/revisions/diff", s.DiffSongRevisions)
+	mux.HandleFunc("POST /songs/{id}/rollback", s.RevertSong)
+
+	mux.HandleFunc("GET /groups", s.ListGroups)
+	mux.HandleFunc("GET /groups/{name}", s.GetGroup)
+	mux.HandleFunc("PATCH /groups/{name}", s.RenameGroup)
+	mux.HandleFunc("DELETE /groups/{name}", s.DeleteGroup)
+	mux.HandleFunc("POST /groups/{name}/merge", s.MergeGroups)
+
+	mux.HandleFunc("GET /trash", s.TrashSongs)
+	mux.HandleFunc("DELETE /purge", s.PurgeTrash)
+	mux.HandleFunc("GET /resync", s.LastResync)
+	mux.HandleFunc("POST /resync", s.Resync)
+	mux.HandleFunc("GET /info-cache", s.InfoCacheStats)
+
+	mux.HandleFunc("POST /search", deprecated("/songs", s.GetData))
+	mux.HandleFunc("POST /text", deprecated("/songs/{id}/text", s.GetText))
+	mux.HandleFunc("DELETE /delete", deprecated("/songs/{id}", s.DeleteSong))
+	mux.HandleFunc("POST /update", deprecated("/songs/{id}", s.UpdateSong))
+	mux.HandleFunc("POST /create", deprecated("/songs", s.CreateSong))
+	mux.HandleFunc("POST /restore", deprecated("/songs/{id}/restore", s.RestoreSong))
+	mux.HandleFunc("GET /revisions", deprecated("/songs/{id}/revisions", s.SongRevisions))
+	mux.HandleFunc("GET /diff", deprecated("/songs/{id}/revisions/diff", s.RevisionDiff))
+	mux.HandleFunc("POST /rollback", deprecated("/songs/{id}/rollback", s.RollbackSong))
+
+	return mux
+}
+
+// deprecated marks the responses of a legacy path with the Deprecation
+// header and a link to the path that replaces it.
+func deprecated(successor string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		h(w, r)
+	}
+}
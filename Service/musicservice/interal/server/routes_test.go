@@ -0,0 +1,188 @@
+package server
+
+import (
+	"client"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"musicservice/interal/app"
+	"musicservice/interal/models"
+	"musicservice/pkg/sql/memory"
+)
+
+// newServer serves an App on an in-memory store holding one enriched song,
+// whose id it returns.
+func newServer(t *testing.T) (*httptest.Server, uint64) {
+	t.Helper()
+
+	db := memory.NewMemory()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := app.NewApp(logger, db, nil, 0, 0.3, 1)
+	id, err := a.CreateSong(models.NewSong{Group: "Muse", Song: "Hysteria", Language: "english"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CompleteEnrichment(id, client.SongDetail{ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(NewMysicServer(logger, *a).Routes())
+	t.Cleanup(ts.Close)
+	return ts, id
+}
+
+func do(t *testing.T, method, url string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestRevisionRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		deprecated bool
+	}{
+		{"resource", "/songs/%d/revisions", false},
+		{"legacy", "/revisions?id=%d", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, id := newServer(t)
+
+			resp := do(t, http.MethodGet, ts.URL+fmt.Sprintf(tt.path, id))
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			if got := resp.Header.Get("Deprecation") == "true"; got != tt.deprecated {
+				t.Errorf("deprecated = %v, want %v", got, tt.deprecated)
+			}
+			var revisions []models.Revision
+			if err := json.NewDecoder(resp.Body).Decode(&revisions); err != nil {
+				t.Fatal(err)
+			}
+			if len(revisions) != 2 {
+				t.Fatalf("got %d revisions, want the creation and the enrichment", len(revisions))
+			}
+		})
+	}
+}
+
+func TestRevisionDiffAndRollbackRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		diff       string
+		rollback   string
+		deprecated bool
+	}{
+		{"resource", "/songs/%d/revisions/diff?from=%d&to=%d", "/songs/%d/rollback?revision=%d", false},
+		{"legacy", "/diff?id=%d&from=%d&to=%d", "/rollback?id=%d&revision=%d", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, id := newServer(t)
+
+			var revisions []models.Revision
+			resp := do(t, http.MethodGet, ts.URL+fmt.Sprintf("/songs/%d/revisions", id))
+			if err := json.NewDecoder(resp.Body).Decode(&revisions); err != nil || len(revisions) != 2 {
+				t.Fatalf("got %d revisions: %v", len(revisions), err)
+			}
+			from, to := revisions[0].ID, revisions[1].ID
+
+			resp = do(t, http.MethodGet, ts.URL+fmt.Sprintf(tt.diff, id, from, to))
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("diff status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			if got := resp.Header.Get("Deprecation") == "true"; got != tt.deprecated {
+				t.Errorf("diff deprecated = %v, want %v", got, tt.deprecated)
+			}
+			var diff models.RevisionDiff
+			if err := json.NewDecoder(resp.Body).Decode(&diff); err != nil {
+				t.Fatal(err)
+			}
+			if diff.SongID != id || len(diff.Changes) == 0 {
+				t.Errorf("got %+v, want the changes enrichment made", diff)
+			}
+
+			resp = do(t, http.MethodPost, ts.URL+fmt.Sprintf(tt.rollback, id, from))
+			if resp.StatusCode != http.StatusNoContent {
+				t.Fatalf("rollback status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+			}
+			if got := resp.Header.Get("Deprecation") == "true"; got != tt.deprecated {
+				t.Errorf("rollback deprecated = %v, want %v", got, tt.deprecated)
+			}
+
+			var song models.Song
+			resp = do(t, http.MethodGet, ts.URL+fmt.Sprintf("/songs/%d", id))
+			if err := json.NewDecoder(resp.Body).Decode(&song); err != nil {
+				t.Fatal(err)
+			}
+			if song.Text != "" {
+				t.Errorf("text = %q, want the song rolled back to before enrichment", song.Text)
+			}
+		})
+	}
+}
+
+func TestRestoreRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		deprecated bool
+	}{
+		{"resource", "/songs/%d/restore", false},
+		{"legacy", "/restore?id=%d", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, id := newServer(t)
+
+			if resp := do(t, http.MethodDelete, ts.URL+fmt.Sprintf("/songs/%d", id)); resp.StatusCode != http.StatusNoContent {
+				t.Fatalf("delete status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+			}
+			if resp := do(t, http.MethodGet, ts.URL+fmt.Sprintf("/songs/%d", id)); resp.StatusCode != http.StatusNotFound {
+				t.Fatalf("trashed song status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+			}
+
+			resp := do(t, http.MethodPost, ts.URL+fmt.Sprintf(tt.path, id))
+			if resp.StatusCode != http.StatusNoContent {
+				t.Fatalf("restore status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+			}
+			if got := resp.Header.Get("Deprecation") == "true"; got != tt.deprecated {
+				t.Errorf("deprecated = %v, want %v", got, tt.deprecated)
+			}
+			if resp := do(t, http.MethodGet, ts.URL+fmt.Sprintf("/songs/%d", id)); resp.StatusCode != http.StatusOK {
+				t.Errorf("restored song status = %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestSongRoutesRejectBadIDs(t *testing.T) {
+	ts, _ := newServer(t)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/songs/abc/restore"},
+		{http.MethodGet, "/songs/0/revisions"},
+		{http.MethodGet, "/songs/abc/revisions/diff?from=1&to=2"},
+		{http.MethodPost, "/songs/abc/rollback?revision=1"},
+	} {
+		if resp := do(t, route.method, ts.URL+route.path); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s %s status = %d, want %d", route.method, route.path, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+}
@@ -22,140 +22,209 @@ func NewMysicServer(logger *slog.Logger, app app.App) *MysicServer {
 
 // GetData godoc
 // @Summary      Get Data 
-// @Description  get songs from database
+// @Description  get songs from database; deprecated alias of GET /songs
 // @Tags         data
 // @Accept       json
 // @Produce      json
-// @Param        page query string true "first page"
-// @Param        limit query string true "count page"
+// @Param        size query int false "songs per page"
+// @Param        offset query int false "songs to skip"
+// @Param        cursor query string false "next cursor of the previous page"
+// @Param        sort query string false "comma-separated sort fields releaseDate, group, song, id and relevance, prefixed with - for descending, like -releaseDate,group"
+// @Param        page query int false "page of the verses of each song's lyrics, counting from 1"
+// @Param        limit query int false "verses of each song's lyrics per page"
 // @Param        input body models.FilterSong true "filter information"
-// @Success      200  {array} models.Song
-// @Failure      400  "Bad request error"
-// @Failure      404 "Not found error"
+// @Success      200  {object} models.SongsPage
+// @Failure      400  {object} server.Problem "Bad request error"
 // @Failure      405 "Method not allowed"
-// @Failure      500  "Internal server error"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Deprecated
 // @Router       /search [post]
 func (s *MysicServer) GetData(w http.ResponseWriter, r *http.Request) {
     s.logger.Info("Getting data music from server" + r.URL.String())
 
-    if r.Method!= "POST" {
-        s.logger.Error("Error getting data from server" + " method not allowed")
-        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-        return
-    }
-
     defer r.Body.Close()
 
-    page := r.URL.Query().Get("page")
-    if page == "" {
-        page = "1"
-    }
+	var filter models.FilterSong
+	err := json.NewDecoder(r.Body).Decode(&filter)
+	if err != nil {
+		s.logger.Error("Error decoding filter song from server" + err.Error())
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidBody, "invalid request body")
+		return
+	}
 
-    limit := r.URL.Query().Get("limit")
-    if limit == "" {
-        limit = "1000"
-    }
+    s.searchSongs(w, r, filter)
+}
 
-    frstpg, err := strconv.Atoi(page)
+// searchSongs writes the page of songs matching filter that the query
+// parameters of r ask for.
+func (s *MysicServer) searchSongs(w http.ResponseWriter, r *http.Request, filter models.FilterSong) {
+    frstpg, err := queryInt(r, "page", 1)
     if err != nil || frstpg < 1 {
-        s.logger.Error("Error converting page to integer", err)
-        http.Error(w, "Invalid page", http.StatusBadRequest)
+        s.logger.Error("Error converting page to integer", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid page")
+        return
     }
 
-    limcnt, err := strconv.Atoi(limit)
-    if err != nil {
-        s.logger.Error("Error converting limit to integer", err)
-        http.Error(w, "Invalid limit", http.StatusBadRequest)
+    limcnt, err := queryInt(r, "limit", 1000)
+    if err != nil || limcnt < 1 {
+        s.logger.Error("Error converting limit to integer", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid limit")
+        return
     }
 
+    var page models.Page
 
-	var filter models.FilterSong
-	err = json.NewDecoder(r.Body).Decode(&filter)
-	if err != nil {
-		s.logger.Error("Error decoding filter song from server" + err.Error())
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
+    page.Limit, err = queryInt(r, "size", app.DefaultPageSize)
+    if err != nil || page.Limit < 1 {
+        s.logger.Error("Error converting size to integer", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid size")
+        return
+    }
+
+    page.Offset, err = queryInt(r, "offset", 0)
+    if err != nil || page.Offset < 0 {
+        s.logger.Error("Error converting offset to integer", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid offset")
+        return
+    }
+
+    page.Cursor = r.URL.Query().Get("cursor")
+    page.Sort = r.URL.Query().Get("sort")
 
-    songs, err := s.app.GetDataMusic(filter, frstpg, limcnt)
+    songs, err := s.app.GetDataMusic(filter, page, frstpg, limcnt)
     if err!= nil {
         s.logger.Error("Error getting data from database" + err.Error())
-        http.Error(w, "Failed to get data from database", http.StatusInternalServerError)
+        fail(w, r, err)
         return
     }
 
-	json.NewEncoder(w).Encode(songs)
 	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(songs)
 	s.logger.Info("Data music returned to server" + r.URL.String())
 }
 
+// queryInt reads an integer query parameter, falling back to def when the
+// parameter is absent.
+func queryInt(r *http.Request, name string, def int) (int, error) {
+    value := r.URL.Query().Get(name)
+    if value == "" {
+        return def, nil
+    }
+    return strconv.Atoi(value)
+}
+
 // GetText godoc
 // @Summary      Get Text 
-// @Description  get text from database
+// @Description  get one page of the verses of the lyrics as plain text in JSON; deprecated alias of GET /songs/{id}/text
 // @Tags         text
 // @Accept       json
 // @Produce      json
-// @Param        page query string true "first page"
-// @Param        limit query string true "count page"
-// @Param        song query string true "song name"
+// @Param        page query int false "page of verses, counting from 1"
+// @Param        limit query int false "verses per page"
+// @Param        id query int false "song id"
+// @Param        group query string false "group name"
+// @Param        song query string false "song name"
 // @Success      200  {object} server.TextSong
-// @Failure      400  "Bad request error"
-// @Failure      404 "Not found error"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
 // @Failure      405 "Method not allowed"
-// @Failure      500  "Internal server error"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Deprecated
 // @Router       /text [post]
 func (s *MysicServer) GetText(w http.ResponseWriter, r *http.Request) {
 	s.logger.Info("Getting text from database " + r.URL.String())
 
+    defer r.Body.Close()
+
+	key, err := songKey(r)
+	if err != nil {
+		s.logger.Debug("Error getting song from server " + err.Error())
+        fail(w, r, err)
+        return
+	}
 
-    if r.Method!= "POST" {
-        s.logger.Error("Error getting data from server" + " method not allowed")
-        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+    lyrics, ok := s.lyricsPage(w, r, key, "limit")
+    if !ok {
         return
     }
 
-    defer r.Body.Close()
+	w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(TextSong{Text: lyrics.Text()})
+	s.logger.Info("Text returned to server" + r.URL.String())
+}
 
-    page := r.URL.Query().Get("page")
-    if page == "" {
-        page = "1"
+// lyricsPage reads the page of the lyrics of a song that the page query
+// parameter and the page size parameter named size ask for. It answers the
+// request itself and reports false when it cannot.
+func (s *MysicServer) lyricsPage(w http.ResponseWriter, r *http.Request, key models.SongKey, size string) (models.Lyrics, bool) {
+    page, err := queryInt(r, "page", 1)
+    if err != nil || page < 1 {
+        s.logger.Error("Error converting page to integer", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid page")
+        return models.Lyrics{}, false
     }
 
-    limit := r.URL.Query().Get("limit")
-    if limit == "" {
-        limit = "1000"
+    verses, err := queryInt(r, size, 1000)
+    if err != nil || verses < 1 {
+        s.logger.Error("Error converting " + size + " to integer", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid " + size)
+        return models.Lyrics{}, false
     }
 
-    frstpg, err := strconv.Atoi(page)
-    if err != nil || frstpg < 1 {
-        s.logger.Error("Error converting page to integer", err)
-        http.Error(w, "Invalid page", http.StatusBadRequest)
+	lyrics, err := s.app.GetLyrics(key, page, verses)
+	if err!= nil {
+        s.logger.Error("Error getting text from database" + err.Error())
+        fail(w, r, err)
+        return models.Lyrics{}, false
     }
+    return lyrics, true
+}
 
-    limcnt, err := strconv.Atoi(limit)
-    if err != nil {
-        s.logger.Error("Error converting limit to integer", err)
-        http.Error(w, "Invalid limit", http.StatusBadRequest)
-    }
+// songKey reads the song addressed by a request: its id or the song name
+// query parameter with an optional group.
+func songKey(r *http.Request) (models.SongKey, error) {
+	id, err := requestID(r)
+	if err != nil {
+		return models.SongKey{}, err
+	}
 
+	key := models.SongKey{
+		ID:    id,
+		Group: r.URL.Query().Get("group"),
+		Song:  r.URL.Query().Get("song"),
+	}
+	if key.ID == 0 && key.Song == "" {
+		return models.SongKey{}, models.Invalid("song_key_required", "song id or name is required")
+	}
+	return key, nil
+}
 
-	song := r.URL.Query().Get("song")
-	if song == "" {
-		s.logger.Debug("Error getting song from server" + " song not found")
-        http.Error(w, "Song not found", http.StatusNotFound)
-        return
+// requestID reads the song id from the {id} path segment or, on the
+// legacy paths, the id query parameter; zero when neither is present.
+func requestID(r *http.Request) (uint64, error) {
+	if value := r.PathValue("id"); value != "" {
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || n == 0 {
+			return 0, models.Invalid(codeInvalidParameter, "invalid id %q", value)
+		}
+		return n, nil
 	}
+	return queryUint(r, "id")
+}
 
-	text, err := s.app.GetTextSong(song, frstpg, limcnt)
-	if err!= nil {
-        s.logger.Error("Error getting text from database" + err.Error())
-        http.Error(w, "Failed to get text from database", http.StatusInternalServerError)
-        return
-    }
+// queryUint reads a positive integer query parameter, zero when it is
+// absent.
+func queryUint(r *http.Request, name string) (uint64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
 
-    json.NewEncoder(w).Encode(TextSong{Text: string(text)})
-	w.Header().Set("Content-Type", "application/json")
-	s.logger.Info("Text returned to server" + r.URL.String())
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || n == 0 {
+		return 0, models.Invalid(codeInvalidParameter, "invalid %s %q", name, value)
+	}
+	return n, nil
 }
 
 // Text is song 
@@ -166,37 +235,39 @@ type TextSong struct {
 
 // DelSong godoc
 // @Summary      Delete Song    
-// @Description  delete song from database
+// @Description  move song to the trash, see /trash and /songs/{id}/restore; deprecated alias of DELETE /songs/{id}
 // @Tags         deleted
 // @Accept       json
 // @Produce      json
-// @Param        song query string true "song name"
+// @Param        id query int false "song id"
+// @Param        group query string false "group name"
+// @Param        song query string false "song name"
 // @Success      204 "success response"
-// @Failure      400  "Bad request error"
-// @Failure      404 "Not found error"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
 // @Failure      405 "Method not allowed"
-// @Failure      500  "Internal server error"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Deprecated
 // @Router       /delete [delete]
 func (s *MysicServer) DeleteSong(w http.ResponseWriter, r *http.Request) {
 	s.logger.Info("Deleting song from database " + r.URL.String())
 
-    if r.Method != "DELETE" {
-        s.logger.Error("Error deleting song from server" + " method not allowed")
-        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+    key, err := songKey(r)
+    if err != nil {
+        s.logger.Debug("Error getting song from server " + err.Error())
+        fail(w, r, err)
         return
     }
 
-    song := r.URL.Query().Get("song")
-    if song == "" {
-        s.logger.Debug("Error getting song from server" + " song not found")
-        http.Error(w, "Song not found", http.StatusNotFound)
-        return
-    }
+    s.deleteSong(w, r, key)
+}
 
-    err := s.app.DeleteSong(song)
+// deleteSong moves the song addressed by key to the trash.
+func (s *MysicServer) deleteSong(w http.ResponseWriter, r *http.Request, key models.SongKey) {
+    err := s.app.DeleteSong(key)
     if err!= nil {
         s.logger.Error("Error deleting song from database" + err.Error())
-        http.Error(w, "Failed to delete song from database", http.StatusInternalServerError)
+        fail(w, r, err)
         return
     }
 
@@ -204,40 +275,304 @@ func (s *MysicServer) DeleteSong(w http.ResponseWriter, r *http.Request) {
     s.logger.Info("Song deleted from server" + r.URL.String())
 }
 
+// TrashSongs godoc
+// @Summary      Trashed songs
+// @Description  list songs in the trash, most recently deleted first
+// @Tags         deleted
+// @Produce      json
+// @Param        size query int false "songs per page"
+// @Param        offset query int false "songs to skip"
+// @Success      200 {object} models.TrashPage
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /trash [get]
+func (s *MysicServer) TrashSongs(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting trashed songs from database " + r.URL.String())
+
+    var page models.Page
+    var err error
+
+    page.Limit, err = queryInt(r, "size", app.DefaultPageSize)
+    if err != nil || page.Limit < 1 {
+        s.logger.Error("Error converting size to integer", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid size")
+        return
+    }
+
+    page.Offset, err = queryInt(r, "offset", 0)
+    if err != nil || page.Offset < 0 {
+        s.logger.Error("Error converting offset to integer", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid offset")
+        return
+    }
+
+    songs, err := s.app.TrashedSongs(page)
+    if err != nil {
+        s.logger.Error("Error getting trashed songs from database" + err.Error())
+        fail(w, r, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(songs)
+    s.logger.Info("Trashed songs returned to server" + r.URL.String())
+}
+
+// RestoreSong godoc
+// @Summary      Restore song
+// @Description  take song out of the trash; deprecated alias of POST /songs/{id}/restore
+// @Tags         deleted
+// @Param        id query int true "song id"
+// @Success      204 "success response"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Deprecated
+// @Router       /restore [post]
+func (s *MysicServer) RestoreSong(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Restoring song in database " + r.URL.String())
+
+    id, err := requestID(r)
+    if err != nil || id == 0 {
+        s.logger.Debug("Error parsing song id", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid id")
+        return
+    }
+
+    s.restoreSong(w, r, id)
+}
+
+func (s *MysicServer) restoreSong(w http.ResponseWriter, r *http.Request, id uint64) {
+    err := s.app.RestoreSong(id)
+    if err != nil {
+        s.logger.Error("Error restoring song in database" + err.Error())
+        fail(w, r, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+    s.logger.Info("Song restored in server" + r.URL.String())
+}
+
+// PurgeTrash godoc
+// @Summary      Purge trash
+// @Description  permanently remove songs kept in the trash longer than the retention period
+// @Tags         deleted
+// @Produce      json
+// @Success      200 {object} server.Purged
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /purge [delete]
+func (s *MysicServer) PurgeTrash(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Purging trash in database " + r.URL.String())
+
+    n, err := s.app.PurgeTrash()
+    if err != nil {
+        s.logger.Error("Error purging trash in database" + err.Error())
+        fail(w, r, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(Purged{Purged: n})
+    s.logger.Info("Trash purged in server" + r.URL.String())
+}
+
+// Purged is count of removed songs
+// @Description Count of songs removed from the trash
+type Purged struct {
+    Purged int64 `json:"purged"`
+}
+
+// SongRevisions godoc
+// @Summary      Song revisions
+// @Description  list the revisions of a song, oldest first; deprecated alias of GET /songs/{id}/revisions
+// @Tags         revisions
+// @Produce      json
+// @Param        id query int false "song id"
+// @Param        group query string false "group name"
+// @Param        song query string false "song name"
+// @Success      200 {array} models.Revision
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Deprecated
+// @Router       /revisions [get]
+func (s *MysicServer) SongRevisions(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting song revisions from database " + r.URL.String())
+
+    key, err := songKey(r)
+    if err != nil {
+        s.logger.Debug("Error getting song from server " + err.Error())
+        fail(w, r, err)
+        return
+    }
+
+    s.songRevisions(w, r, key)
+}
+
+func (s *MysicServer) songRevisions(w http.ResponseWriter, r *http.Request, key models.SongKey) {
+    revisions, err := s.app.SongRevisions(key)
+    if err != nil {
+        s.logger.Error("Error getting song revisions from database" + err.Error())
+        fail(w, r, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(revisions)
+    s.logger.Info("Song revisions returned to server" + r.URL.String())
+}
+
+// RevisionDiff godoc
+// @Summary      Revision diff
+// @Description  compare two revisions of a song; deprecated alias of GET /songs/{id}/revisions/diff
+// @Tags         revisions
+// @Produce      json
+// @Param        id query int true "song id"
+// @Param        from query int true "revision id to compare from"
+// @Param        to query int true "revision id to compare to"
+// @Success      200 {object} models.RevisionDiff
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Deprecated
+// @Router       /diff [get]
+func (s *MysicServer) RevisionDiff(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting revision diff from database " + r.URL.String())
+
+    id, err := requestID(r)
+    if err != nil || id == 0 {
+        s.logger.Debug("Error parsing song id", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid id")
+        return
+    }
+
+    s.revisionDiff(w, r, id)
+}
+
+// revisionDiff compares the revisions of song id named by the from and to
+// query parameters.
+func (s *MysicServer) revisionDiff(w http.ResponseWriter, r *http.Request, id uint64) {
+    var ids [2]uint64
+    for i, name := range []string{"from", "to"} {
+        n, err := queryUint(r, name)
+        if err != nil || n == 0 {
+            s.logger.Debug("Error parsing " + name, slog.Any("error", err))
+            writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid " + name)
+            return
+        }
+        ids[i] = n
+    }
+
+    diff, err := s.app.RevisionDiff(id, ids[0], ids[1])
+    if err != nil {
+        s.logger.Error("Error getting revision diff from database" + err.Error())
+        fail(w, r, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(diff)
+    s.logger.Info("Revision diff returned to server" + r.URL.String())
+}
+
+// RollbackSong godoc
+// @Summary      Roll back song
+// @Description  restore the fields of a song to one of its revisions; deprecated alias of POST /songs/{id}/rollback
+// @Tags         revisions
+// @Param        id query int true "song id"
+// @Param        revision query int true "revision id"
+// @Success      204 "success response"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Deprecated
+// @Router       /rollback [post]
+func (s *MysicServer) RollbackSong(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Rolling back song in database " + r.URL.String())
+
+    id, err := requestID(r)
+    if err != nil || id == 0 {
+        s.logger.Debug("Error parsing song id", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid id")
+        return
+    }
+
+    s.rollbackSong(w, r, id)
+}
+
+// rollbackSong restores song id to the revision named by the revision
+// query parameter.
+func (s *MysicServer) rollbackSong(w http.ResponseWriter, r *http.Request, id uint64) {
+    revision, err := queryUint(r, "revision")
+    if err != nil || revision == 0 {
+        s.logger.Debug("Error parsing revision id", slog.Any("error", err))
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid revision")
+        return
+    }
+
+    err = s.app.RollbackSong(id, revision)
+    if err != nil {
+        s.logger.Error("Error rolling back song in database" + err.Error())
+        fail(w, r, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+    s.logger.Info("Song rolled back in server" + r.URL.String())
+}
+
 // UpdateSong godoc
 // @Summary      Update song 
-// @Description  update song from database
+// @Description  update song from database; deprecated alias of PATCH /songs/{id}
 // @Tags         update
 // @Accept       json
 // @Produce      json
+// @Param        id query int false "song id, otherwise the song is looked up by its name in the body"
+// @Param        group query string false "group of the song when looked up by name"
 // @Param        input body models.FilterSong true "update song"
 // @Success      204 "success response"
-// @Failure      400  "Bad request error"
-// @Failure      404 "Not found error"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      409 {object} server.Problem "Conflict error"
 // @Failure      405 "Method not allowed"
-// @Failure      500  "Internal server error"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Deprecated
 // @Router       /update [post]
 func (s *MysicServer) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	s.logger.Info("Update song from database  " + r.URL.String())
 
-    if r.Method!= "POST" {
-        s.logger.Error("Error updating song from server" + " method not allowed")
-        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-        return
-    }
-
 	var song models.FilterSong
 	err := json.NewDecoder(r.Body).Decode(&song)
 	if err!= nil {
         s.logger.Debug("Error decoding song from server" + err.Error())
-        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidBody, "invalid request body")
         return
     }
 
-	err = s.app.UpdateSong(song)
+	key := models.SongKey{Group: r.URL.Query().Get("group"), Song: song.Song}
+	key.ID, err = requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	s.updateSong(w, r, key, song)
+}
+
+// updateSong sets the non-empty fields of song on the song addressed by key.
+func (s *MysicServer) updateSong(w http.ResponseWriter, r *http.Request, key models.SongKey, song models.FilterSong) {
+	err := s.app.UpdateSong(key, song)
 	if err!= nil {
         s.logger.Error("Error updating song from database" + err.Error())
-        http.Error(w, "Failed to update song from database", http.StatusInternalServerError)
+        fail(w, r, err)
         return
     }
 
@@ -247,44 +582,47 @@ func (s *MysicServer) UpdateSong(w http.ResponseWriter, r *http.Request) {
 
 // CreateSong godoc
 // @Summary      Create song 
-// @Description  create song from database
+// @Description  create song from database; deprecated alias of POST /songs
 // @Tags         create
 // @Accept       json
 // @Produce      json
 // @Param        input body models.NewSong true "song struct"
 // @Success      200 {object} server.NewID
-// @Failure      400  "Bad request error"
-// @Failure      404 "Not found error"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      409 {object} server.Problem "Conflict error"
 // @Failure      405 "Method not allowed"
-// @Failure      500  "Internal server error"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Deprecated
 // @Router       /create [post]
 func (s *MysicServer) CreateSong(w http.ResponseWriter, r *http.Request) {
+	s.createSong(w, r, http.StatusOK)
+}
+
+// createSong creates the song described by the request body and answers
+// with its id and the given status.
+func (s *MysicServer) createSong(w http.ResponseWriter, r *http.Request, status int) {
 	s.logger.Info("Creating new song in database " + r.URL.String())
-    
-    if r.Method == "POST" {
-        s.logger.Error("Error creating song from server" + " method not allowed")
-        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-        return
-    }
 
     var newsong models.NewSong
     err := json.NewDecoder(r.Body).Decode(&newsong)
     if err != nil {
         s.logger.Debug("Error decoding new song from server" + err.Error())
-        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        writeProblem(w, r, http.StatusBadRequest, codeInvalidBody, "invalid request body")
         return
     }
 
     id, err := s.app.CreateSong(newsong)
     if err != nil {
         s.logger.Error("Error creating song in database" + err.Error())
-        http.Error(w, "Failed to create song in database", http.StatusInternalServerError)
+        fail(w, r, err)
         return
     }
 
-    json.NewEncoder(w).Encode(NewID{ID: id})
     w.Header().Set("Content-Type", "application/json")
-    
+    w.Header().Set("Location", "/songs/" + strconv.FormatUint(id, 10))
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(NewID{ID: id})
+
 	s.logger.Info("New song created in server " + r.URL.String())
 }
 
@@ -292,4 +630,4 @@ func (s *MysicServer) CreateSong(w http.ResponseWriter, r *http.Request) {
 // @Description ID song
 type NewID struct {
     ID uint64 `json:"id"`
-}
\ No newline at end of file
+}
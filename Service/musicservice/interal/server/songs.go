@@ -0,0 +1,342 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"musicservice/interal/models"
+)
+
+// ListSongs godoc
+// @Summary      List songs
+// @Description  get one page of the songs matching the filter query parameters
+// @Tags         songs
+// @Produce      json
+// @Param        group query string false "group name"
+// @Param        song query string false "song name"
+// @Param        releaseDate query string false "exact release date, DD.MM.YYYY"
+// @Param        releasedFrom query string false "released on or after, DD.MM.YYYY"
+// @Param        releasedTo query string false "released on or before, DD.MM.YYYY"
+// @Param        year query int false "release year"
+// @Param        decade query int false "release decade, like 1990"
+// @Param        text query string false "words of the lyrics"
+// @Param        textLanguage query string false "language of text, detected when empty or auto"
+// @Param        link query string false "song link"
+// @Param        language query string false "song language"
+// @Param        match query string false "exact or fuzzy matching of group and song" Enums(exact, fuzzy)
+// @Param        threshold query number false "similarity a fuzzy match needs"
+// @Param        size query int false "songs per page"
+// @Param        offset query int false "songs to skip"
+// @Param        cursor query string false "next cursor of the previous page"
+// @Param        sort query string false "comma-separated sort fields releaseDate, group, song, id and relevance, prefixed with - for descending, like -releaseDate,group"
+// @Param        page query int false "page of the verses of each song's lyrics, counting from 1"
+// @Param        limit query int false "verses of each song's lyrics per page"
+// @Success      200  {object} models.SongsPage
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs [get]
+func (s *MysicServer) ListSongs(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Listing songs from server " + r.URL.String())
+
+	filter, err := filterQuery(r)
+	if err != nil {
+		s.logger.Debug("Error parsing filter " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	s.searchSongs(w, r, filter)
+}
+
+// filterQuery reads a song filter from the query parameters, named like the
+// fields of the FilterSong body of /search.
+func filterQuery(r *http.Request) (models.FilterSong, error) {
+	q := r.URL.Query()
+	filter := models.FilterSong{
+		Group:        q.Get("group"),
+		Song:         q.Get("song"),
+		ReleaseDate:  q.Get("releaseDate"),
+		ReleasedFrom: q.Get("releasedFrom"),
+		ReleasedTo:   q.Get("releasedTo"),
+		Text:         q.Get("text"),
+		Link:         q.Get("link"),
+		Language:     q.Get("language"),
+		TextLanguage: q.Get("textLanguage"),
+		Match:        q.Get("match"),
+	}
+
+	var err error
+	filter.Year, err = queryInt(r, "year", 0)
+	if err != nil {
+		return models.FilterSong{}, models.Invalid(codeInvalidParameter, "invalid year %q", q.Get("year"))
+	}
+	filter.Decade, err = queryInt(r, "decade", 0)
+	if err != nil {
+		return models.FilterSong{}, models.Invalid(codeInvalidParameter, "invalid decade %q", q.Get("decade"))
+	}
+	if value := q.Get("threshold"); value != "" {
+		filter.Threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return models.FilterSong{}, models.Invalid(codeInvalidParameter, "invalid threshold %q", value)
+		}
+	}
+	return filter, nil
+}
+
+// PostSong godoc
+// @Summary      Create song
+// @Description  create a song right away and queue it for enrichment, which fetches its release date, lyrics and link from the info service in the background; see /songs/{id}/enrichment for its progress
+// @Tags         songs
+// @Accept       json
+// @Produce      json
+// @Param        input body models.NewSong true "song struct"
+// @Success      201 {object} server.NewID
+// @Header       201 {string} Location "path of the new song"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      409 {object} server.Problem "Conflict error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs [post]
+func (s *MysicServer) PostSong(w http.ResponseWriter, r *http.Request) {
+	s.createSong(w, r, http.StatusCreated)
+}
+
+// GetSong godoc
+// @Summary      Get song
+// @Description  get one song with its full lyrics
+// @Tags         songs
+// @Produce      json
+// @Param        id path int true "song id"
+// @Success      200  {object} models.Song
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Router       /songs/{id} [get]
+func (s *MysicServer) GetSong(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting song from database " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	song, err := s.app.GetSong(id)
+	if err != nil {
+		s.logger.Debug("Error getting song from database " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(song)
+	s.logger.Info("Song returned to server " + r.URL.String())
+}
+
+// PatchSong godoc
+// @Summary      Update song
+// @Description  apply a JSON merge patch to a song: fields left out stay as they are and null fields are cleared; the title can be changed as well, as long as the group has no other song with the new title
+// @Tags         songs
+// @Accept       application/merge-patch+json
+// @Accept       json
+// @Param        id path int true "song id"
+// @Param        input body models.SongPatch true "fields to change, null to clear"
+// @Success      204 "success response"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      409 {object} server.Problem "Conflict error"
+// @Failure      405 "Method not allowed"
+// @Failure      415  {object} server.Problem "Unsupported media type"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/{id} [patch]
+func (s *MysicServer) PatchSong(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Patching song in database " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	if !mergePatch(r.Header.Get("Content-Type")) {
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type", "send application/merge-patch+json")
+		return
+	}
+
+	var patch models.SongPatch
+	err = json.NewDecoder(r.Body).Decode(&patch)
+	if err != nil {
+		s.logger.Debug("Error decoding patch from server " + err.Error())
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidBody, "invalid request body, want an object of strings and nulls")
+		return
+	}
+
+	err = s.app.PatchSong(id, patch)
+	if err != nil {
+		s.logger.Debug("Error patching song in database " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	s.logger.Info("Song patched from server " + r.URL.String())
+}
+
+// mergePatch reports whether a Content-Type is one a merge patch may be
+// sent as. Plain JSON and a missing type are accepted too, for clients
+// that predate merge patches.
+func mergePatch(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/merge-patch+json" || mediaType == "application/json"
+}
+
+// RemoveSong godoc
+// @Summary      Delete song
+// @Description  move a song to the trash, see /trash and /songs/{id}/restore
+// @Tags         songs
+// @Param        id path int true "song id"
+// @Success      204 "success response"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/{id} [delete]
+func (s *MysicServer) RemoveSong(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Removing song from database " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	s.deleteSong(w, r, models.SongKey{ID: id})
+}
+
+// RestoreTrashedSong godoc
+// @Summary      Restore song
+// @Description  take a song out of the trash
+// @Tags         songs
+// @Param        id path int true "song id"
+// @Success      204 "success response"
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/{id}/restore [post]
+func (s *MysicServer) RestoreTrashedSong(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Restoring song in database " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	s.restoreSong(w, r, id)
+}
+
+// GetSongText godoc
+// @Summary      Get song text
+// @Description  get a page of the verses of the lyrics of a song, as JSON or, with format=text or Accept: text/plain, as plain text linking the next page
+// @Tags         songs
+// @Produce      json
+// @Produce      plain
+// @Param        id path int true "song id"
+// @Param        page query int false "page of verses, counting from 1"
+// @Param        size query int false "verses per page"
+// @Param        format query string false "response format" Enums(json, text)
+// @Success      200  {object} models.Lyrics
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/{id}/text [get]
+func (s *MysicServer) GetSongText(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Getting song text from database " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.HasPrefix(r.Header.Get("Accept"), "text/plain") {
+		format = "text"
+	}
+	if format != "" && format != "json" && format != "text" {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidParameter, "invalid format "+strconv.Quote(format))
+		return
+	}
+
+	lyrics, ok := s.lyricsPage(w, r, models.SongKey{ID: id}, "size")
+	if !ok {
+		return
+	}
+
+	if format == "text" {
+		if lyrics.HasNext {
+			next := *r.URL
+			q := next.Query()
+			q.Set("page", strconv.Itoa(lyrics.Page+1))
+			next.RawQuery = q.Encode()
+			w.Header().Set("Link", "<"+next.RequestURI()+`>; rel="next"`)
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, lyrics.Text())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lyrics)
+	s.logger.Info("Song text returned to server " + r.URL.String())
+}
+
+// SearchSongText godoc
+// @Summary      Search song text
+// @Description  find the lines of the lyrics of a song that contain a phrase, ignoring case and accents, grouped by verse with the character offsets of every occurrence and highlighted lines
+// @Tags         songs
+// @Produce      json
+// @Param        id path int true "song id"
+// @Param        q query string true "phrase to find"
+// @Success      200  {object} models.LyricsSearch
+// @Failure      400  {object} server.Problem "Bad request error"
+// @Failure      404 {object} server.Problem "Not found error"
+// @Failure      405 "Method not allowed"
+// @Failure      500  {object} server.Problem "Internal server error"
+// @Router       /songs/{id}/text/search [get]
+func (s *MysicServer) SearchSongText(w http.ResponseWriter, r *http.Request) {
+	s.logger.Info("Searching song text in database " + r.URL.String())
+
+	id, err := requestID(r)
+	if err != nil {
+		s.logger.Debug("Error parsing song id " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	matches, err := s.app.SearchLyrics(models.SongKey{ID: id}, r.URL.Query().Get("q"))
+	if err != nil {
+		s.logger.Error("Error searching song text in database " + err.Error())
+		fail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(matches)
+	s.logger.Info("Song text matches returned to server " + r.URL.String())
+}
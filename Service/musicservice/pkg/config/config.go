@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +19,59 @@ type ConfigPostgres struct {
 	Port string
 }
 
+type ConfigStorage struct {
+	Driver string
+	SQLitePath string
+}
+
+type ConfigTrash struct {
+	Retention time.Duration
+	PurgeInterval time.Duration
+}
+
+type ConfigSearch struct {
+	FuzzyThreshold float64
+}
+
+type ConfigImport struct {
+	Workers int
+}
+
+type ConfigUpstream struct {
+	Timeout time.Duration
+	Retries int
+	Backoff time.Duration
+	MaxBackoff time.Duration
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+type ConfigEnrich struct {
+	Workers int
+	PollInterval time.Duration
+	Lease time.Duration
+	MaxAttempts int
+	Backoff time.Duration
+	MaxBackoff time.Duration
+}
+
+type ConfigInfoCache struct {
+	Size int
+	TTL time.Duration
+	NegativeTTL time.Duration
+}
+
+type ConfigMetadata struct {
+	Providers []string
+	FilesPath string
+}
+
+type ConfigResync struct {
+	Interval time.Duration
+	Batch int
+	Apply bool
+}
+
 type ServerConfig struct {
 	Host string
 	Port string
@@ -28,8 +84,19 @@ type APIConfig struct {
 type ConfigMigrator struct {
 	MigrationsPath string
 	MigrationsTable string
+	SQLiteMigrationsPath string
 }
 
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+const (
+	ProviderHTTP  = "http"
+	ProviderFiles = "files"
+)
+
 
 func ReturnedDatabase() (ConfigPostgres, error) {
 	err := godotenv.Load(Dir("config.env"))
@@ -48,6 +115,245 @@ func ReturnedDatabase() (ConfigPostgres, error) {
 	return configPostgres, nil
 }
 
+func ReturnedStorage() (ConfigStorage, error) {
+	err := godotenv.Load(Dir("config.env"))
+	if err!= nil {
+		return ConfigStorage{}, err
+	}
+
+	configStorage := ConfigStorage{
+		Driver:     getEnv("DB_DRIVER", DriverPostgres),
+		SQLitePath: getEnv("SQLITE_PATH", ""),
+	}
+
+	switch configStorage.Driver {
+	case DriverPostgres:
+	case DriverSQLite:
+		if configStorage.SQLitePath == "" {
+			return ConfigStorage{}, fmt.Errorf("SQLITE_PATH is required for driver %q", DriverSQLite)
+		}
+		configStorage.SQLitePath = Dir(configStorage.SQLitePath)
+	default:
+		return ConfigStorage{}, fmt.Errorf("unknown DB_DRIVER %q", configStorage.Driver)
+	}
+
+	return configStorage, nil
+}
+
+func ReturnedTrash() (ConfigTrash, error) {
+	err := godotenv.Load(Dir("config.env"))
+	if err!= nil {
+		return ConfigTrash{}, err
+	}
+
+	retention, err := time.ParseDuration(getEnv("TRASH_RETENTION", "720h"))
+	if err != nil || retention <= 0 {
+		return ConfigTrash{}, fmt.Errorf("invalid TRASH_RETENTION %q: must be a positive duration", getEnv("TRASH_RETENTION", "720h"))
+	}
+
+	interval, err := time.ParseDuration(getEnv("TRASH_PURGE_INTERVAL", "1h"))
+	if err != nil || interval < 0 {
+		return ConfigTrash{}, fmt.Errorf("invalid TRASH_PURGE_INTERVAL %q: must be a non-negative duration, 0 to turn scheduled purges off", getEnv("TRASH_PURGE_INTERVAL", "1h"))
+	}
+
+	return ConfigTrash{Retention: retention, PurgeInterval: interval}, nil
+}
+
+func ReturnedSearch() (ConfigSearch, error) {
+	err := godotenv.Load(Dir("config.env"))
+	if err!= nil {
+		return ConfigSearch{}, err
+	}
+
+	threshold, err := strconv.ParseFloat(getEnv("FUZZY_THRESHOLD", "0.3"), 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		return ConfigSearch{}, fmt.Errorf("invalid FUZZY_THRESHOLD %q: must be in (0, 1]", getEnv("FUZZY_THRESHOLD", "0.3"))
+	}
+
+	return ConfigSearch{FuzzyThreshold: threshold}, nil
+}
+
+func ReturnedImport() (ConfigImport, error) {
+	err := godotenv.Load(Dir("config.env"))
+	if err!= nil {
+		return ConfigImport{}, err
+	}
+
+	workers, err := strconv.Atoi(getEnv("IMPORT_WORKERS", "4"))
+	if err != nil || workers < 1 {
+		return ConfigImport{}, fmt.Errorf("invalid IMPORT_WORKERS %q: must be a positive integer", getEnv("IMPORT_WORKERS", "4"))
+	}
+
+	return ConfigImport{Workers: workers}, nil
+}
+
+func ReturnedUpstream() (ConfigUpstream, error) {
+	err := godotenv.Load(Dir("config.env"))
+	if err!= nil {
+		return ConfigUpstream{}, err
+	}
+
+	var configUpstream ConfigUpstream
+	durations := []struct {
+		key, def string
+		dst *time.Duration
+	}{
+		{"UPSTREAM_TIMEOUT", "5s", &configUpstream.Timeout},
+		{"UPSTREAM_BACKOFF", "200ms", &configUpstream.Backoff},
+		{"UPSTREAM_MAX_BACKOFF", "2s", &configUpstream.MaxBackoff},
+		{"UPSTREAM_BREAKER_COOLDOWN", "30s", &configUpstream.BreakerCooldown},
+	}
+	for _, d := range durations {
+		*d.dst, err = time.ParseDuration(getEnv(d.key, d.def))
+		if err != nil || *d.dst < 0 {
+			return ConfigUpstream{}, fmt.Errorf("invalid %s %q: must be a non-negative duration", d.key, getEnv(d.key, d.def))
+		}
+	}
+
+	counts := []struct {
+		key, def string
+		dst *int
+	}{
+		{"UPSTREAM_RETRIES", "2", &configUpstream.Retries},
+		{"UPSTREAM_BREAKER_FAILURES", "5", &configUpstream.BreakerFailures},
+	}
+	for _, c := range counts {
+		*c.dst, err = strconv.Atoi(getEnv(c.key, c.def))
+		if err != nil || *c.dst < 0 {
+			return ConfigUpstream{}, fmt.Errorf("invalid %s %q: must be a non-negative integer", c.key, getEnv(c.key, c.def))
+		}
+	}
+
+	return configUpstream, nil
+}
+
+func ReturnedEnrich() (ConfigEnrich, error) {
+	err := godotenv.Load(Dir("config.env"))
+	if err!= nil {
+		return ConfigEnrich{}, err
+	}
+
+	var configEnrich ConfigEnrich
+	durations := []struct {
+		key, def string
+		dst *time.Duration
+	}{
+		{"ENRICH_POLL_INTERVAL", "2s", &configEnrich.PollInterval},
+		{"ENRICH_LEASE", "1m", &configEnrich.Lease},
+		{"ENRICH_BACKOFF", "30s", &configEnrich.Backoff},
+		{"ENRICH_MAX_BACKOFF", "1h", &configEnrich.MaxBackoff},
+	}
+	for _, d := range durations {
+		*d.dst, err = time.ParseDuration(getEnv(d.key, d.def))
+		if err != nil || *d.dst <= 0 {
+			return ConfigEnrich{}, fmt.Errorf("invalid %s %q: must be a positive duration", d.key, getEnv(d.key, d.def))
+		}
+	}
+
+	counts := []struct {
+		key, def string
+		dst *int
+	}{
+		{"ENRICH_WORKERS", "4", &configEnrich.Workers},
+		{"ENRICH_MAX_ATTEMPTS", "5", &configEnrich.MaxAttempts},
+	}
+	for _, c := range counts {
+		*c.dst, err = strconv.Atoi(getEnv(c.key, c.def))
+		if err != nil || *c.dst < 1 {
+			return ConfigEnrich{}, fmt.Errorf("invalid %s %q: must be a positive integer", c.key, getEnv(c.key, c.def))
+		}
+	}
+
+	if configEnrich.MaxBackoff < configEnrich.Backoff {
+		return ConfigEnrich{}, fmt.Errorf("invalid ENRICH_MAX_BACKOFF %q: must not be shorter than ENRICH_BACKOFF", getEnv("ENRICH_MAX_BACKOFF", "1h"))
+	}
+
+	return configEnrich, nil
+}
+
+func ReturnedInfoCache() (ConfigInfoCache, error) {
+	err := godotenv.Load(Dir("config.env"))
+	if err!= nil {
+		return ConfigInfoCache{}, err
+	}
+
+	size, err := strconv.Atoi(getEnv("INFO_CACHE_SIZE", "10000"))
+	if err != nil || size < 0 {
+		return ConfigInfoCache{}, fmt.Errorf("invalid INFO_CACHE_SIZE %q: must be a non-negative integer, 0 to turn the cache off", getEnv("INFO_CACHE_SIZE", "10000"))
+	}
+
+	configInfoCache := ConfigInfoCache{Size: size}
+	durations := []struct {
+		key, def string
+		dst *time.Duration
+	}{
+		{"INFO_CACHE_TTL", "1h", &configInfoCache.TTL},
+		{"INFO_CACHE_NEGATIVE_TTL", "5m", &configInfoCache.NegativeTTL},
+	}
+	for _, d := range durations {
+		*d.dst, err = time.ParseDuration(getEnv(d.key, d.def))
+		if err != nil || *d.dst < 0 {
+			return ConfigInfoCache{}, fmt.Errorf("invalid %s %q: must be a non-negative duration", d.key, getEnv(d.key, d.def))
+		}
+	}
+
+	return configInfoCache, nil
+}
+
+func ReturnedMetadata() (ConfigMetadata, error) {
+	err := godotenv.Load(Dir("config.env"))
+	if err!= nil {
+		return ConfigMetadata{}, err
+	}
+
+	configMetadata := ConfigMetadata{FilesPath: getEnv("METADATA_FILES", "")}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(getEnv("METADATA_PROVIDERS", ProviderHTTP), ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name != ProviderHTTP && name != ProviderFiles:
+			return ConfigMetadata{}, fmt.Errorf("unknown provider %q in METADATA_PROVIDERS", name)
+		case seen[name]:
+			return ConfigMetadata{}, fmt.Errorf("provider %q is listed twice in METADATA_PROVIDERS", name)
+		}
+		seen[name] = true
+		configMetadata.Providers = append(configMetadata.Providers, name)
+	}
+
+	if seen[ProviderFiles] {
+		if configMetadata.FilesPath == "" {
+			return ConfigMetadata{}, fmt.Errorf("METADATA_FILES is required for provider %q", ProviderFiles)
+		}
+		configMetadata.FilesPath = Dir(configMetadata.FilesPath)
+	}
+
+	return configMetadata, nil
+}
+
+func ReturnedResync() (ConfigResync, error) {
+	err := godotenv.Load(Dir("config.env"))
+	if err!= nil {
+		return ConfigResync{}, err
+	}
+
+	interval, err := time.ParseDuration(getEnv("RESYNC_INTERVAL", "24h"))
+	if err != nil || interval < 0 {
+		return ConfigResync{}, fmt.Errorf("invalid RESYNC_INTERVAL %q: must be a non-negative duration, 0 to turn scheduled resyncs off", getEnv("RESYNC_INTERVAL", "24h"))
+	}
+
+	batch, err := strconv.Atoi(getEnv("RESYNC_BATCH", "100"))
+	if err != nil || batch < 1 {
+		return ConfigResync{}, fmt.Errorf("invalid RESYNC_BATCH %q: must be a positive integer", getEnv("RESYNC_BATCH", "100"))
+	}
+
+	apply, err := strconv.ParseBool(getEnv("RESYNC_APPLY", "true"))
+	if err != nil {
+		return ConfigResync{}, fmt.Errorf("invalid RESYNC_APPLY: %w", err)
+	}
+
+	return ConfigResync{Interval: interval, Batch: batch, Apply: apply}, nil
+}
+
 func InitConfigMigration() (ConfigPostgres, ConfigMigrator, error) {
 	err := godotenv.Load(Dir("config.env"))
 	if err!= nil {
@@ -65,6 +371,7 @@ func InitConfigMigration() (ConfigPostgres, ConfigMigrator, error) {
 	configMigrator := ConfigMigrator{
         MigrationsPath: getEnv("MIGRATIONS_PATH", ""),
         MigrationsTable: getEnv("MIGRATIONS_TABLE", ""),
+        SQLiteMigrationsPath: getEnv("SQLITE_MIGRATIONS_PATH", ""),
     }
 
 	return configPostgres, configMigrator, nil
@@ -106,6 +413,8 @@ func Dir(envFile string) string {
 		panic(err)
 	}    
     currentDir = strings.Replace(currentDir, filepath.Join("Service","musicservice","cmd", "migration"), "", -1)
+    currentDir = strings.Replace(currentDir, filepath.Join("Service","musicservice","cmd", "songimport"), "", -1)
+    currentDir = strings.Replace(currentDir, filepath.Join("Service","musicservice","cmd", "fakeinfo"), "", -1)
 	currentDir = strings.Replace(currentDir, filepath.Join("Service","musicservice","cmd"), "", -1)
     return filepath.Join(currentDir, envFile)
 }
\ No newline at end of file
@@ -0,0 +1,202 @@
+// Package infocache keeps the answers of the song info service for a while,
+// so songs created, imported or retried again do not ask it every time.
+// Details are kept for a TTL, songs the service does not know for a shorter
+// one, and the least recently used entries make room for new ones.
+package infocache
+
+import (
+	"client"
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Getter gets the details of a song from the info service. The generated
+// API client satisfies it.
+type Getter interface {
+	GetInfoWithResponse(ctx context.Context, params *client.GetInfoParams, reqEditors ...client.RequestEditorFn) (*client.GetInfoResponse, error)
+}
+
+// Config sets how answers are cached.
+type Config struct {
+	// Size is how many songs are kept at most.
+	Size int
+	// TTL is how long the details of a song are kept and NegativeTTL how
+	// long a song the service does not know is.
+	TTL         time.Duration
+	NegativeTTL time.Duration
+}
+
+// Stats model info
+// @Description Counters of the song info cache since the service started
+type Stats struct {
+	// Hits counts calls answered from the cache, NegativeHits the share
+	// of them answered with a song the service does not know.
+	Hits         uint64  `json:"hits"`
+	NegativeHits uint64  `json:"negativeHits"`
+	Misses       uint64  `json:"misses"`
+	Evictions    uint64  `json:"evictions"`
+	Entries      int     `json:"entries"`
+	Size         int     `json:"size"`
+	HitRate      float64 `json:"hitRate"`
+}
+
+type key struct {
+	group, song string
+}
+
+type entry struct {
+	key     key
+	resp    *client.GetInfoResponse
+	expires time.Time
+}
+
+// call is a request to the service other callers asking for the same song
+// wait for instead of making their own.
+type call struct {
+	done chan struct{}
+	resp *client.GetInfoResponse
+	err  error
+}
+
+// Cache is a Getter answering from memory when it can and asking next
+// otherwise. It is safe for concurrent use.
+type Cache struct {
+	next   Getter
+	config Config
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[key]*list.Element
+	calls   map[key]*call
+	stats   Stats
+}
+
+// New returns a cache in front of next.
+func New(next Getter, config Config) *Cache {
+	return &Cache{
+		next:    next,
+		config:  config,
+		lru:     list.New(),
+		entries: make(map[key]*list.Element),
+		calls:   make(map[key]*call),
+	}
+}
+
+type refreshKey struct{}
+
+// Refresh returns a context whose calls skip the cached answer and ask the
+// service, caching what it says.
+func Refresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+// GetInfoWithResponse returns the cached answer for the song, or asks next
+// and caches the details it returns or that it does not know the song.
+// Errors and other statuses are not cached. Callers asking for a song that
+// is being fetched wait for that answer and count as hits.
+func (c *Cache) GetInfoWithResponse(ctx context.Context, params *client.GetInfoParams, reqEditors ...client.RequestEditorFn) (*client.GetInfoResponse, error) {
+	k := key{group: params.Group, song: params.Song}
+	refresh, _ := ctx.Value(refreshKey{}).(bool)
+
+	c.mu.Lock()
+	if !refresh {
+		if resp, ok := c.lookup(k); ok {
+			c.mu.Unlock()
+			return resp, nil
+		}
+		if cl, ok := c.calls[k]; ok {
+			c.stats.Hits++
+			c.mu.Unlock()
+			select {
+			case <-cl.done:
+				return cl.resp, cl.err
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	c.stats.Misses++
+	cl := &call{done: make(chan struct{})}
+	c.calls[k] = cl
+	c.mu.Unlock()
+
+	cl.resp, cl.err = c.next.GetInfoWithResponse(ctx, params, reqEditors...)
+
+	c.mu.Lock()
+	if c.calls[k] == cl {
+		delete(c.calls, k)
+	}
+	if cl.err == nil {
+		c.store(k, cl.resp)
+	}
+	c.mu.Unlock()
+	close(cl.done)
+
+	return cl.resp, cl.err
+}
+
+// lookup returns the cached answer for k unless it has expired.
+func (c *Cache) lookup(k key) (*client.GetInfoResponse, bool) {
+	el, ok := c.entries[k]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+	c.stats.Hits++
+	if e.resp.StatusCode() == http.StatusNotFound {
+		c.stats.NegativeHits++
+	}
+	return e.resp, true
+}
+
+// store caches resp for as long as its status allows, evicting the least
+// recently used entries to stay within Size.
+func (c *Cache) store(k key, resp *client.GetInfoResponse) {
+	var ttl time.Duration
+	switch {
+	case resp.StatusCode() == http.StatusOK && resp.JSON200 != nil:
+		ttl = c.config.TTL
+	case resp.StatusCode() == http.StatusNotFound:
+		ttl = c.config.NegativeTTL
+	}
+	if ttl <= 0 || c.config.Size <= 0 {
+		return
+	}
+
+	if el, ok := c.entries[k]; ok {
+		c.remove(el)
+	}
+	for c.lru.Len() >= c.config.Size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+	c.entries[k] = c.lru.PushFront(&entry{key: k, resp: resp, expires: time.Now().Add(ttl)})
+}
+
+func (c *Cache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
+
+// Stats returns the counters of the cache.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	stats.Size = c.config.Size
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
@@ -0,0 +1,206 @@
+package infocache
+
+import (
+	"client"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubGetter answers every song with status, counting the calls, and
+// waits for release first when it is set.
+type stubGetter struct {
+	status  int
+	err     error
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (g *stubGetter) GetInfoWithResponse(ctx context.Context, params *client.GetInfoParams, reqEditors ...client.RequestEditorFn) (*client.GetInfoResponse, error) {
+	g.calls.Add(1)
+	if g.release != nil {
+		<-g.release
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	resp := &client.GetInfoResponse{HTTPResponse: &http.Response{StatusCode: g.status}}
+	if g.status == http.StatusOK {
+		resp.JSON200 = &client.SongDetail{Text: params.Group + " " + params.Song}
+	}
+	return resp, nil
+}
+
+func get(t *testing.T, ctx context.Context, c *Cache, song string) *client.GetInfoResponse {
+	t.Helper()
+
+	resp, err := c.GetInfoWithResponse(ctx, &client.GetInfoParams{Group: "Muse", Song: song})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestCachesDetails(t *testing.T) {
+	next := &stubGetter{status: http.StatusOK}
+	c := New(next, Config{Size: 10, TTL: time.Hour, NegativeTTL: time.Hour})
+
+	first := get(t, context.Background(), c, "Hysteria")
+	if second := get(t, context.Background(), c, "Hysteria"); second != first {
+		t.Errorf("second call was not answered from the cache")
+	}
+	if n := next.calls.Load(); n != 1 {
+		t.Errorf("next called %d times, want 1", n)
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 || stats.Size != 10 || stats.HitRate != 0.5 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	next := &stubGetter{status: http.StatusOK}
+	c := New(next, Config{Size: 10, TTL: 20 * time.Millisecond, NegativeTTL: time.Hour})
+
+	get(t, context.Background(), c, "Hysteria")
+	time.Sleep(30 * time.Millisecond)
+	get(t, context.Background(), c, "Hysteria")
+
+	if n := next.calls.Load(); n != 2 {
+		t.Errorf("next called %d times, want the expired entry fetched again", n)
+	}
+}
+
+func TestNegativeTTL(t *testing.T) {
+	next := &stubGetter{status: http.StatusNotFound}
+	c := New(next, Config{Size: 10, TTL: time.Hour, NegativeTTL: 20 * time.Millisecond})
+
+	get(t, context.Background(), c, "Unknown")
+	if resp := get(t, context.Background(), c, "Unknown"); resp.StatusCode() != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", resp.StatusCode(), http.StatusNotFound)
+	}
+	if n := next.calls.Load(); n != 1 {
+		t.Errorf("next called %d times, want the 404 cached", n)
+	}
+	if stats := c.Stats(); stats.NegativeHits != 1 {
+		t.Errorf("negative hits = %d, want 1", stats.NegativeHits)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	get(t, context.Background(), c, "Unknown")
+	if n := next.calls.Load(); n != 2 {
+		t.Errorf("next called %d times, want the 404 kept only for NegativeTTL", n)
+	}
+}
+
+func TestNotCached(t *testing.T) {
+	tests := []struct {
+		name string
+		next *stubGetter
+	}{
+		{"server error", &stubGetter{status: http.StatusInternalServerError}},
+		{"call error", &stubGetter{err: errors.New("connection refused")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(tt.next, Config{Size: 10, TTL: time.Hour, NegativeTTL: time.Hour})
+			for range 2 {
+				c.GetInfoWithResponse(context.Background(), &client.GetInfoParams{Group: "Muse", Song: "Hysteria"})
+			}
+			if n := tt.next.calls.Load(); n != 2 {
+				t.Errorf("next called %d times, want every call to ask it", n)
+			}
+			if stats := c.Stats(); stats.Entries != 0 {
+				t.Errorf("entries = %d, want 0", stats.Entries)
+			}
+		})
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	next := &stubGetter{status: http.StatusOK}
+	c := New(next, Config{Size: 2, TTL: time.Hour, NegativeTTL: time.Hour})
+
+	get(t, context.Background(), c, "Hysteria")
+	get(t, context.Background(), c, "Uprising")
+	get(t, context.Background(), c, "Hysteria")
+	get(t, context.Background(), c, "Madness")
+
+	calls := next.calls.Load()
+	get(t, context.Background(), c, "Hysteria")
+	if n := next.calls.Load(); n != calls {
+		t.Errorf("recently used entry was evicted")
+	}
+	get(t, context.Background(), c, "Uprising")
+	if n := next.calls.Load(); n != calls+1 {
+		t.Errorf("least recently used entry was kept")
+	}
+
+	if stats := c.Stats(); stats.Evictions != 2 || stats.Entries != 2 {
+		t.Errorf("stats = %+v, want 2 evictions and 2 entries", stats)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	next := &stubGetter{status: http.StatusOK}
+	c := New(next, Config{Size: 10, TTL: time.Hour, NegativeTTL: time.Hour})
+
+	first := get(t, context.Background(), c, "Hysteria")
+	refreshed := get(t, Refresh(context.Background()), c, "Hysteria")
+	if refreshed == first {
+		t.Fatal("refresh was answered from the cache")
+	}
+	if got := get(t, context.Background(), c, "Hysteria"); got != refreshed {
+		t.Errorf("refresh did not overwrite the cached entry")
+	}
+	if n := next.calls.Load(); n != 2 {
+		t.Errorf("next called %d times, want 2", n)
+	}
+	if stats := c.Stats(); stats.Entries != 1 {
+		t.Errorf("entries = %d, want 1", stats.Entries)
+	}
+}
+
+func TestConcurrentCallersShareOneCall(t *testing.T) {
+	next := &stubGetter{status: http.StatusOK, release: make(chan struct{})}
+	c := New(next, Config{Size: 10, TTL: time.Hour, NegativeTTL: time.Hour})
+
+	const callers = 10
+	resps := make([]*client.GetInfoResponse, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i], errs[i] = c.GetInfoWithResponse(context.Background(), &client.GetInfoParams{Group: "Muse", Song: "Hysteria"})
+		}()
+	}
+
+	// Let every caller reach the cache before the call answers.
+	for c.Stats().Hits+c.Stats().Misses < callers {
+		time.Sleep(time.Millisecond)
+	}
+	close(next.release)
+	wg.Wait()
+
+	if n := next.calls.Load(); n != 1 {
+		t.Errorf("next called %d times, want 1", n)
+	}
+	for i, resp := range resps {
+		if errs[i] != nil {
+			t.Errorf("caller %d: %v", i, errs[i])
+		}
+		if resp != resps[0] {
+			t.Errorf("caller %d got another answer", i)
+		}
+	}
+	if stats := c.Stats(); stats.Hits != callers-1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want %d hits and 1 miss", stats, callers-1)
+	}
+}
@@ -0,0 +1,89 @@
+package lang
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Default is the language assumed when nothing better can be detected.
+const Default = "english"
+
+// supported names the languages that have a Postgres text search
+// configuration of the same name.
+var supported = map[string]bool{
+	"simple": true, "arabic": true, "danish": true, "dutch": true,
+	"english": true, "finnish": true, "french": true, "german": true,
+	"greek": true, "hungarian": true, "indonesian": true, "irish": true,
+	"italian": true, "lithuanian": true, "nepali": true, "norwegian": true,
+	"portuguese": true, "romanian": true, "russian": true, "serbian": true,
+	"spanish": true, "swedish": true, "tamil": true, "turkish": true,
+}
+
+// stopwords holds a few of the most frequent words of the Latin-script
+// languages Detect tells apart.
+var stopwords = map[string][]string{
+	"english":    {"the", "and", "you", "to", "of", "is", "it", "in", "me", "my", "i", "a", "that", "your", "don't", "i'm"},
+	"german":     {"der", "die", "und", "ich", "du", "nicht", "das", "ist", "ein", "mich", "mein", "wir", "sie", "zu", "mit"},
+	"french":     {"le", "la", "les", "et", "je", "tu", "est", "pas", "une", "des", "que", "moi", "mon", "pour", "dans"},
+	"spanish":    {"el", "los", "las", "y", "yo", "tu", "es", "no", "una", "que", "mi", "por", "con", "para", "del"},
+	"italian":    {"il", "lo", "gli", "e", "io", "sei", "non", "che", "una", "di", "mi", "per", "con", "sono", "della"},
+	"portuguese": {"o", "os", "as", "e", "eu", "você", "não", "uma", "que", "meu", "por", "com", "para", "do", "da"},
+	"dutch":      {"de", "het", "en", "ik", "jij", "niet", "een", "is", "mijn", "wij", "ze", "van", "met", "voor", "op"},
+	"swedish":    {"och", "jag", "du", "inte", "en", "ett", "är", "min", "vi", "de", "att", "med", "för", "på", "det"},
+}
+
+// Supported reports whether name is a language search can be done in.
+func Supported(name string) bool {
+	return supported[name]
+}
+
+// Detect guesses the language of a text from its script and, for Latin
+// script, from the frequent words it contains.
+func Detect(text string) string {
+	var latin, cyrillic, greek, arabic int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		}
+	}
+
+	switch max(latin, cyrillic, greek, arabic) {
+	case 0:
+		return Default
+	case cyrillic:
+		if strings.ContainsAny(text, "ђћџљњјЂЋЏЉЊЈ") {
+			return "serbian"
+		}
+		return "russian"
+	case greek:
+		return "greek"
+	case arabic:
+		return "arabic"
+	}
+
+	words := make(map[string]int)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		words[w]++
+	}
+
+	best, score := Default, 0
+	for _, name := range []string{"english", "german", "french", "spanish", "italian", "portuguese", "dutch", "swedish"} {
+		n := 0
+		for _, w := range stopwords[name] {
+			n += words[w]
+		}
+		if n > score {
+			best, score = name, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,114 @@
+package metadata
+
+import (
+	"client"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"musicservice/interal/models"
+)
+
+// FileSong is an entry of a song details file. Any of the details may be
+// left out for another provider to fill in.
+type FileSong struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+type fileKey struct {
+	group, song string
+}
+
+func keyOf(group, song string) fileKey {
+	return fileKey{group: strings.ToLower(strings.TrimSpace(group)), song: strings.ToLower(strings.TrimSpace(song))}
+}
+
+// Files is the provider answering from JSON files, each holding an array
+// of FileSong. Songs are matched by group and name regardless of case.
+type Files struct {
+	songs map[fileKey]client.SongDetail
+}
+
+// NewFiles reads the songs of path, a JSON file or a directory whose .json
+// files are all read. The files are read once; a song may be listed only
+// once across them.
+func NewFiles(path string) (*Files, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		paths = nil
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".json") {
+				paths = append(paths, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f := &Files{songs: make(map[fileKey]client.SongDetail)}
+	for _, p := range paths {
+		if err := f.read(p); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (f *Files) read(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var songs []FileSong
+	if err := json.Unmarshal(data, &songs); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	for i, s := range songs {
+		if strings.TrimSpace(s.Group) == "" || strings.TrimSpace(s.Song) == "" {
+			return fmt.Errorf("failed to read %s: entry %d has no group or song", path, i)
+		}
+		k := keyOf(s.Group, s.Song)
+		if _, ok := f.songs[k]; ok {
+			return fmt.Errorf("failed to read %s: song %q of group %q is listed twice", path, s.Song, s.Group)
+		}
+		f.songs[k] = client.SongDetail{ReleaseDate: s.ReleaseDate, Text: s.Text, Link: s.Link}
+	}
+	return nil
+}
+
+func (f *Files) Name() string {
+	return NameFiles
+}
+
+// Len returns how many songs the files list.
+func (f *Files) Len() int {
+	return len(f.songs)
+}
+
+// SongInfo returns the details of a song listed in the files.
+func (f *Files) SongInfo(ctx context.Context, group, song string) (client.SongDetail, error) {
+	detail, ok := f.songs[keyOf(group, song)]
+	if !ok {
+		return client.SongDetail{}, models.NotFound(ErrNotFound.Code, "no song info for %q of group %q in files", song, group)
+	}
+	return detail, nil
+}
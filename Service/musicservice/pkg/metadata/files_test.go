@@ -0,0 +1,121 @@
+package metadata
+
+import (
+	"client"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"musicservice/interal/models"
+)
+
+// writeFile writes content to name under dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFilesSongInfo(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "songs.json", `[
+		{"group": "Muse", "song": "Hysteria", "releaseDate": "01.12.2003", "text": "It's bugging me", "link": "https://example.com"},
+		{"group": "Muse", "song": "Uprising", "text": "Paranoia is in bloom"}
+	]`)
+	f, err := NewFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", f.Len())
+	}
+
+	tests := []struct {
+		name  string
+		group string
+		song  string
+		want  client.SongDetail
+		err   error
+	}{
+		{"listed", "Muse", "Hysteria", client.SongDetail{ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com"}, nil},
+		{"case and spaces ignored", " muse ", "HYSTERIA", client.SongDetail{ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com"}, nil},
+		{"partial details", "Muse", "Uprising", client.SongDetail{Text: "Paranoia is in bloom"}, nil},
+		{"unknown song", "Muse", "Madness", client.SongDetail{}, ErrNotFound},
+		{"unknown group", "Queen", "Hysteria", client.SongDetail{}, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.SongInfo(context.Background(), tt.group, tt.song)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil && !errors.Is(err, models.ErrNotFound) {
+				t.Errorf("err = %v, want an error of the not found kind", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFilesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.json", `[{"group": "Muse", "song": "Hysteria", "text": "a"}]`)
+	writeFile(t, dir, "nested/b.JSON", `[{"group": "Queen", "song": "Bohemian Rhapsody", "text": "b"}]`)
+	writeFile(t, dir, "notes.txt", `not json at all`)
+
+	f, err := NewFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Len() != 2 {
+		t.Errorf("Len() = %d, want the songs of both JSON files", f.Len())
+	}
+	if _, err := f.SongInfo(context.Background(), "Queen", "Bohemian Rhapsody"); err != nil {
+		t.Errorf("song of the nested file: %v", err)
+	}
+}
+
+func TestNewFilesRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{"malformed JSON", map[string]string{"a.json": `[{"group": "Muse"`}, "failed to read"},
+		{"no group", map[string]string{"a.json": `[{"song": "Hysteria"}]`}, "entry 0 has no group or song"},
+		{"no song", map[string]string{"a.json": `[{"group": "Muse", "song": " "}]`}, "entry 0 has no group or song"},
+		{"listed twice in a file", map[string]string{"a.json": `[{"group": "Muse", "song": "Hysteria"}, {"group": "muse", "song": "hysteria"}]`}, "listed twice"},
+		{"listed twice across files", map[string]string{
+			"a.json": `[{"group": "Muse", "song": "Hysteria"}]`,
+			"b.json": `[{"group": "Muse", "song": "Hysteria"}]`,
+		}, "listed twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, dir, name, content)
+			}
+
+			_, err := NewFiles(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.err)
+			}
+		})
+	}
+
+	if _, err := NewFiles(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing path: err = %v, want %v", err, os.ErrNotExist)
+	}
+}
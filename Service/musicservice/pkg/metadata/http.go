@@ -0,0 +1,58 @@
+package metadata
+
+import (
+	"client"
+	"context"
+	"errors"
+	"net/http"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/infocache"
+	"musicservice/pkg/upstream"
+)
+
+// InfoClient gets the details of a song from the info service. The
+// generated API client satisfies it, and so does a cache in front of it.
+type InfoClient interface {
+	GetInfoWithResponse(ctx context.Context, params *client.GetInfoParams, reqEditors ...client.RequestEditorFn) (*client.GetInfoResponse, error)
+}
+
+// HTTP is the provider asking the song info service.
+type HTTP struct {
+	client InfoClient
+}
+
+// NewHTTP returns a provider asking the info service through client.
+func NewHTTP(client InfoClient) *HTTP {
+	return &HTTP{client: client}
+}
+
+func (h *HTTP) Name() string {
+	return NameHTTP
+}
+
+// Cache returns the cache in front of the info service, or nil when calls
+// are not cached.
+func (h *HTTP) Cache() *infocache.Cache {
+	cache, _ := h.client.(*infocache.Cache)
+	return cache
+}
+
+// SongInfo asks the info service for the details of a song.
+func (h *HTTP) SongInfo(ctx context.Context, group, song string) (client.SongDetail, error) {
+	resp, err := h.client.GetInfoWithResponse(ctx, &client.GetInfoParams{Group: group, Song: song})
+	if errors.Is(err, upstream.ErrCircuitOpen) {
+		return client.SongDetail{}, ErrUnavailable
+	}
+	if err != nil {
+		return client.SongDetail{}, models.Upstream("upstream_failed", "failed to get song info: %v", err)
+	}
+
+	switch {
+	case resp.StatusCode() == http.StatusNotFound:
+		return client.SongDetail{}, models.NotFound(ErrNotFound.Code, "no song info for %q of group %q", song, group)
+	case resp.StatusCode() != http.StatusOK || resp.JSON200 == nil:
+		return client.SongDetail{}, models.Upstream("upstream_failed", "failed to get song info: status code %d", resp.StatusCode())
+	}
+	return *resp.JSON200, nil
+}
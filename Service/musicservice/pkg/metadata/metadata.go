@@ -0,0 +1,18 @@
+// Package metadata holds the sources the details of a song can come from:
+// the song info service and local JSON files. Every provider tells a song it
+// does not know, ErrNotFound, apart from failing to answer.
+package metadata
+
+import "musicservice/interal/models"
+
+// Names of the providers, as they are listed in the configuration.
+const (
+	NameHTTP  = "http"
+	NameFiles = "files"
+)
+
+// ErrNotFound is returned by a provider that does not know a song.
+var ErrNotFound = models.NotFound("song_info_not_found", "no song info")
+
+// ErrUnavailable is returned by a provider that is not taking calls.
+var ErrUnavailable = models.Upstream("upstream_unavailable", "song info service is unavailable")
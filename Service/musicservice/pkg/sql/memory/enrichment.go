@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"client"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/lang"
+)
+
+// QueueSong saves a song without its details together with the job that
+// enriches it. A song without a language gets the default one until the
+// language is detected from its lyrics.
+func (m *Memory) QueueSong(song models.NewSong) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.find(song.Group, song.Song); ok {
+		return 0, fmt.Errorf("%w: %q of group %q", models.ErrSongExists, song.Song, song.Group)
+	}
+
+	language := song.Language
+	if language == "" {
+		language = lang.Default
+	}
+
+	m.groups[song.Group] = struct{}{}
+	m.nextID++
+	m.songs[m.nextID] = models.Song{
+		ID:       strconv.FormatUint(m.nextID, 10),
+		Group:    song.Group,
+		Song:     song.Song,
+		Language: language,
+	}
+	now := time.Now()
+	m.enrichments[m.nextID] = &models.Enrichment{
+		SongID:        m.nextID,
+		Status:        models.EnrichmentPending,
+		NextAttemptAt: &now,
+		UpdatedAt:     &now,
+	}
+	m.detect[m.nextID] = song.Language == ""
+	m.saveRevision(m.nextID, models.ActionCreate, models.Song{}, m.songs[m.nextID])
+	return m.nextID, nil
+}
+
+// ClaimEnrichments marks up to limit due jobs as running for lease and
+// returns them, the longest due first.
+func (m *Memory) ClaimEnrichments(limit int, lease time.Duration) ([]models.EnrichmentJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	live := m.live()
+	var due []*models.Enrichment
+	for id, e := range m.enrichments {
+		if _, ok := live[id]; !ok {
+			continue
+		}
+		if (e.Status == models.EnrichmentPending || e.Status == models.EnrichmentRunning) && !e.NextAttemptAt.After(now) {
+			due = append(due, e)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(*due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+		}
+		return due[i].SongID < due[j].SongID
+	})
+
+	jobs := make([]models.EnrichmentJob, 0, min(limit, len(due)))
+	for _, e := range due[:min(limit, len(due))] {
+		until := now.Add(lease)
+		e.Status, e.NextAttemptAt, e.UpdatedAt = models.EnrichmentRunning, &until, &now
+		e.Attempts++
+
+		song := live[e.SongID]
+		jobs = append(jobs, models.EnrichmentJob{
+			SongID:         e.SongID,
+			Group:          song.Group,
+			Song:           song.Song,
+			Attempt:        e.Attempts,
+			DetectLanguage: m.detect[e.SongID],
+		})
+	}
+	return jobs, nil
+}
+
+// CompleteEnrichment saves the details of a song and marks its job done.
+// An empty language keeps the one the song has.
+func (m *Memory) CompleteEnrichment(id uint64, data client.SongDetail, language string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.live()[id]
+	if !ok {
+		return models.ErrSongNotFound
+	}
+
+	s.ReleaseDate, s.Text, s.Link = data.ReleaseDate, data.Text, data.Link
+	if language != "" {
+		s.Language = language
+	}
+	m.saveRevision(id, models.ActionUpdate, m.songs[id], s)
+	m.songs[id] = s
+
+	if e, ok := m.enrichments[id]; ok {
+		now := time.Now()
+		e.Status, e.LastError, e.UpdatedAt = models.EnrichmentDone, "", &now
+	}
+	return nil
+}
+
+// FailEnrichment records why enriching a song failed. The job runs again at
+// retryAt, or is given up on when retryAt is zero.
+func (m *Memory) FailEnrichment(id uint64, reason string, retryAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.enrichments[id]
+	if !ok {
+		return models.ErrSongNotFound
+	}
+
+	now := time.Now()
+	e.Status, e.LastError, e.NextAttemptAt, e.UpdatedAt = models.EnrichmentPending, reason, &retryAt, &now
+	if retryAt.IsZero() {
+		e.Status, e.NextAttemptAt = models.EnrichmentFailed, &now
+	}
+	return nil
+}
+
+// Enrichment returns the enrichment status of a song. Songs saved without
+// a job count as done.
+func (m *Memory) Enrichment(id uint64) (models.Enrichment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.live()[id]; !ok {
+		return models.Enrichment{}, models.ErrSongNotFound
+	}
+
+	e, ok := m.enrichments[id]
+	if !ok {
+		return models.Enrichment{SongID: id, Status: models.EnrichmentDone}, nil
+	}
+
+	status := *e
+	if status.Status != models.EnrichmentPending {
+		status.NextAttemptAt = nil
+	}
+	return status, nil
+}
+
+// RetryEnrichment queues a failed enrichment again with a fresh count of
+// attempts.
+func (m *Memory) RetryEnrichment(id uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.live()[id]; !ok {
+		return models.ErrSongNotFound
+	}
+
+	e, ok := m.enrichments[id]
+	if !ok || e.Status != models.EnrichmentFailed {
+		return models.ErrEnrichmentNotFailed
+	}
+
+	now := time.Now()
+	e.Status, e.Attempts, e.LastError, e.NextAttemptAt, e.UpdatedAt = models.EnrichmentPending, 0, "", &now, &now
+	return nil
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+
+	"musicservice/interal/models"
+)
+
+// Groups returns the requested page of groups by name along with the
+// number of groups.
+func (m *Memory) Groups(page models.Page) ([]models.Group, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := m.songCounts()
+	groups := make([]models.Group, 0, len(m.groups))
+	for name := range m.groups {
+		groups = append(groups, models.Group{Name: name, SongCount: counts[name]})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	total := len(groups)
+
+	groups = groups[min(page.Offset, len(groups)):]
+	if page.Limit > 0 {
+		groups = groups[:min(page.Limit, len(groups))]
+	}
+	return groups, total, nil
+}
+
+// Group returns one group with the number of its songs.
+func (m *Memory) Group(name string) (models.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.groups[name]; !ok {
+		return models.Group{}, models.ErrGroupNotFound
+	}
+	return models.Group{Name: name, SongCount: m.songCounts()[name]}, nil
+}
+
+// RenameGroup gives a group a name no other group has, moving its songs,
+// trashed ones included, along.
+func (m *Memory) RenameGroup(name, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[name]; !ok {
+		return fmt.Errorf("%w: %q", models.ErrGroupNotFound, name)
+	}
+	if _, ok := m.groups[newName]; ok {
+		return fmt.Errorf("%w: %q", models.ErrGroupExists, newName)
+	}
+
+	m.groups[newName] = struct{}{}
+	m.moveGroup(name, newName)
+	return nil
+}
+
+// MergeGroups moves the songs of a group into another existing group and
+// removes the emptied group.
+func (m *Memory) MergeGroups(name, into string) error {
+	if name == into {
+		return models.Invalid("invalid_group", "cannot merge group %q into itself", name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, g := range []string{name, into} {
+		if _, ok := m.groups[g]; !ok {
+			return fmt.Errorf("%w: %q", models.ErrGroupNotFound, g)
+		}
+	}
+
+	for _, s := range m.live() {
+		if s.Group != name {
+			continue
+		}
+		if _, ok := m.find(into, s.Song); ok {
+			return fmt.Errorf("%w: %q is in both groups", models.ErrSongExists, s.Song)
+		}
+	}
+
+	m.moveGroup(name, into)
+	return nil
+}
+
+// DeleteGroup removes a group that has no songs, in the trash or not.
+func (m *Memory) DeleteGroup(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[name]; !ok {
+		return fmt.Errorf("%w: %q", models.ErrGroupNotFound, name)
+	}
+
+	n := 0
+	for _, s := range m.songs {
+		if s.Group == name {
+			n++
+		}
+	}
+	if n > 0 {
+		return fmt.Errorf("%w: %q has %d", models.ErrGroupNotEmpty, name, n)
+	}
+
+	delete(m.groups, name)
+	return nil
+}
+
+// moveGroup moves every song of group from to group to, recording the move
+// in the history of each song, and removes group from; callers hold the
+// lock.
+func (m *Memory) moveGroup(from, to string) {
+	ids := make([]uint64, 0)
+	for id, s := range m.songs {
+		if s.Group == from {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		before := m.songs[id]
+		after := before
+		after.Group = to
+		m.songs[id] = after
+		m.saveRevision(id, models.ActionUpdate, before, after)
+	}
+	delete(m.groups, from)
+}
+
+// songCounts counts the live songs of every group; callers hold the lock.
+func (m *Memory) songCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, s := range m.live() {
+		counts[s.Group]++
+	}
+	return counts
+}
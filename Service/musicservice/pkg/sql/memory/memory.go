@@ -0,0 +1,537 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/fuzzy"
+	"musicservice/pkg/lang"
+)
+
+// Memory is a song store kept entirely in process memory. It mirrors the
+// filter semantics of the Postgres store and is meant for tests and demos.
+type Memory struct {
+	mu     sync.RWMutex
+	nextID uint64
+	groups  map[string]struct{}
+	songs   map[uint64]models.Song
+	deleted map[uint64]time.Time
+
+	nextRevision uint64
+	revisions    map[uint64][]models.Revision
+
+	enrichments map[uint64]*models.Enrichment
+	detect      map[uint64]bool
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		groups:  make(map[string]struct{}),
+		songs:   make(map[uint64]models.Song),
+		deleted: make(map[uint64]time.Time),
+
+		revisions: make(map[uint64][]models.Revision),
+
+		enrichments: make(map[uint64]*models.Enrichment),
+		detect:      make(map[uint64]bool),
+	}
+}
+
+// GetSongs returns the requested page of songs matching the filter along
+// with the number of matching songs across all pages.
+func (m *Memory) GetSongs(filter map[string]string, page models.Page) ([]models.Song, int, error) {
+	for k := range filter {
+		if !filterColumn(k) {
+			return nil, 0, fmt.Errorf("unknown column %q", k)
+		}
+	}
+
+	threshold, err := strconv.ParseFloat(filter["threshold"], 64)
+	if err != nil && filter["match"] == "fuzzy" {
+		return nil, 0, fmt.Errorf("invalid threshold %q: %w", filter["threshold"], err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	text, ranked := filter["text"]
+	songs := make([]models.Song, 0, 10)
+	for _, song := range m.live() {
+		if !match(song, filter) {
+			continue
+		}
+		if filter["match"] == "fuzzy" {
+			song.Similarity = similarity(song, filter)
+			if song.Similarity < threshold {
+				continue
+			}
+		}
+		if ranked {
+			song.Rank = rank(song.Text, text)
+		}
+		songs = append(songs, song)
+	}
+
+	sort.Slice(songs, func(i, j int) bool {
+		if len(page.Order) > 0 {
+			return compareSongs(songs[i], songs[j], page.Order) < 0
+		}
+		if songs[i].Similarity != songs[j].Similarity {
+			return songs[i].Similarity > songs[j].Similarity
+		}
+		if songs[i].Rank != songs[j].Rank {
+			return songs[i].Rank > songs[j].Rank
+		}
+		return id(songs[i]) < id(songs[j])
+	})
+	total := len(songs)
+
+	if page.After > 0 {
+		kept := songs[:0]
+		for _, song := range songs {
+			if id(song) > page.After {
+				kept = append(kept, song)
+			}
+		}
+		songs = kept
+	}
+
+	songs = songs[min(page.Offset, len(songs)):]
+	if page.Limit > 0 {
+		songs = songs[:min(page.Limit, len(songs))]
+	}
+	return songs, total, nil
+}
+
+// ExportSongs calls fn with every song matching the filter in id order.
+// Lyrics are left out unless withText is set.
+func (m *Memory) ExportSongs(filter map[string]string, withText bool, fn func(models.Song) error) error {
+	songs, _, err := m.GetSongs(filter, models.Page{Order: []models.SortKey{{Field: models.SortID}}})
+	if err != nil {
+		return err
+	}
+
+	for _, song := range songs {
+		song.Rank, song.Similarity = 0, 0
+		if !withText {
+			song.Text = ""
+		}
+		if err := fn(song); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SongIDs returns the ids of the songs with the given title, restricted
+// to one group unless group is empty.
+func (m *Memory) SongIDs(group, song string) ([]uint64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []uint64
+	for id, s := range m.live() {
+		if s.Song == song && (group == "" || s.Group == group) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// GetSong returns the song with the given id unless it is in the trash.
+func (m *Memory) GetSong(id uint64) (models.Song, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.live()[id]
+	if !ok {
+		return models.Song{}, models.ErrSongNotFound
+	}
+	return s, nil
+}
+
+func (m *Memory) GetText(id uint64) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.live()[id]
+	if !ok {
+		return nil, models.ErrSongNotFound
+	}
+	return []byte(s.Text), nil
+}
+
+func (m *Memory) UpdateSong(id uint64, values map[string]string) error {
+	for k := range values {
+		if !updateColumn(k) {
+			return fmt.Errorf("unknown column %q", k)
+		}
+	}
+	if len(values) == 0 {
+		return models.ErrNothingToUpdate
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.live()[id]
+	if !ok {
+		return models.ErrSongNotFound
+	}
+
+	for k, v := range values {
+		switch k {
+		case "group":
+			s.Group = v
+		case "song":
+			s.Song = v
+		case "link":
+			s.Link = v
+		case "releasedate":
+			s.ReleaseDate = v
+		case "text":
+			s.Text = v
+		case "language":
+			s.Language = v
+		}
+	}
+	if other, ok := m.find(s.Group, s.Song); ok && other != id {
+		return fmt.Errorf("%w: %q of group %q", models.ErrSongExists, s.Song, s.Group)
+	}
+
+	m.groups[s.Group] = struct{}{}
+	m.saveRevision(id, models.ActionUpdate, m.songs[id], s)
+	m.songs[id] = s
+	return nil
+}
+
+// DeleteSong moves a song to the trash.
+func (m *Memory) DeleteSong(id uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.live()[id]; !ok {
+		return models.ErrSongNotFound
+	}
+	m.deleted[id] = time.Now()
+	m.saveRevision(id, models.ActionDelete, m.songs[id], m.songs[id])
+	return nil
+}
+
+// TrashedSongs returns the requested page of trashed songs, most recently
+// deleted first, along with the number of songs in the trash.
+func (m *Memory) TrashedSongs(page models.Page) ([]models.TrashedSong, int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	songs := make([]models.TrashedSong, 0, len(m.deleted))
+	for id, at := range m.deleted {
+		songs = append(songs, models.TrashedSong{Song: m.songs[id], DeletedAt: at})
+	}
+	sort.Slice(songs, func(i, j int) bool {
+		if !songs[i].DeletedAt.Equal(songs[j].DeletedAt) {
+			return songs[i].DeletedAt.After(songs[j].DeletedAt)
+		}
+		return id(songs[i].Song) > id(songs[j].Song)
+	})
+	total := len(songs)
+
+	songs = songs[min(page.Offset, len(songs)):]
+	if page.Limit > 0 {
+		songs = songs[:min(page.Limit, len(songs))]
+	}
+	return songs, total, nil
+}
+
+// RestoreSong takes a song out of the trash.
+func (m *Memory) RestoreSong(id uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.deleted[id]; !ok {
+		return models.ErrSongNotFound
+	}
+
+	s := m.songs[id]
+	if _, ok := m.find(s.Group, s.Song); ok {
+		return fmt.Errorf("%w: %q of group %q", models.ErrSongExists, s.Song, s.Group)
+	}
+	delete(m.deleted, id)
+	m.saveRevision(id, models.ActionRestore, s, s)
+	return nil
+}
+
+// PurgeSongs permanently removes the songs trashed before the given time.
+func (m *Memory) PurgeSongs(before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for id, at := range m.deleted {
+		if at.Before(before) {
+			delete(m.deleted, id)
+			delete(m.songs, id)
+			delete(m.revisions, id)
+			delete(m.enrichments, id)
+			delete(m.detect, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *Memory) SaveGroup(group string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.groups[group] = struct{}{}
+	return nil
+}
+
+// Revisions returns the history of a song, oldest first, empty for a song
+// with none. Songs that are missing or in the trash are not found.
+func (m *Memory) Revisions(songID uint64) ([]models.Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.live()[songID]; !ok {
+		return nil, models.ErrSongNotFound
+	}
+	return append([]models.Revision{}, m.revisions[songID]...), nil
+}
+
+// Revision returns one revision of a song.
+func (m *Memory) Revision(songID, revisionID uint64) (models.Revision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.revision(songID, revisionID)
+}
+
+// RollbackSong restores the fields of a song to their values at the given
+// revision and records the rollback as a new revision.
+func (m *Memory) RollbackSong(songID, revisionID uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rev, err := m.revision(songID, revisionID)
+	if err != nil {
+		return err
+	}
+
+	before, ok := m.live()[songID]
+	if !ok {
+		return models.ErrSongNotFound
+	}
+
+	after := rev.Snapshot
+	after.ID = before.ID
+	if after.Language == "" {
+		after.Language = lang.Default
+	}
+	if other, ok := m.find(after.Group, after.Song); ok && other != songID {
+		return fmt.Errorf("%w: %q of group %q", models.ErrSongExists, after.Song, after.Group)
+	}
+
+	m.groups[after.Group] = struct{}{}
+	m.songs[songID] = after
+	m.saveRevision(songID, models.ActionRollback, before, after)
+	return nil
+}
+
+// revision looks a revision up; callers hold the lock.
+func (m *Memory) revision(songID, revisionID uint64) (models.Revision, error) {
+	for _, rev := range m.revisions[songID] {
+		if rev.ID == revisionID {
+			return rev, nil
+		}
+	}
+	return models.Revision{}, models.ErrRevisionNotFound
+}
+
+// saveRevision records the state of a song after a change; callers hold
+// the lock.
+func (m *Memory) saveRevision(songID uint64, action string, before, after models.Song) {
+	m.nextRevision++
+	m.revisions[songID] = append(m.revisions[songID], models.Revision{
+		ID:        m.nextRevision,
+		SongID:    songID,
+		Action:    action,
+		Snapshot:  after,
+		Changes:   before.Changes(after),
+		CreatedAt: time.Now(),
+	})
+}
+
+// live returns the songs that are not in the trash; callers hold the lock.
+func (m *Memory) live() map[uint64]models.Song {
+	songs := make(map[uint64]models.Song, len(m.songs))
+	for id, s := range m.songs {
+		if _, ok := m.deleted[id]; !ok {
+			songs[id] = s
+		}
+	}
+	return songs
+}
+
+// find looks a live song up by its group and title; callers hold the lock.
+func (m *Memory) find(group, song string) (uint64, bool) {
+	for id, s := range m.live() {
+		if s.Group == group && s.Song == song {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func filterColumn(k string) bool {
+	switch k {
+	case "group", "song", "link", "releasedate", "releasedfrom", "releasedto",
+		"text", "language", "textlanguage", "match", "threshold":
+		return true
+	}
+	return false
+}
+
+func updateColumn(k string) bool {
+	switch k {
+	case "group", "song", "link", "releasedate", "text", "language":
+		return true
+	}
+	return false
+}
+
+// match reports whether a song passes the exact conditions of the filter;
+// fuzzy names are left to similarity.
+func match(song models.Song, filter map[string]string) bool {
+	fuzzy := filter["match"] == "fuzzy"
+	for k, v := range filter {
+		switch k {
+		case "group":
+			if !fuzzy && song.Group != v {
+				return false
+			}
+		case "song":
+			if !fuzzy && song.Song != v {
+				return false
+			}
+		case "link":
+			if song.Link != v {
+				return false
+			}
+		case "releasedate":
+			if song.ReleaseDate != v {
+				return false
+			}
+		case "releasedfrom":
+			if c, ok := compareDates(song.ReleaseDate, v); !ok || c < 0 {
+				return false
+			}
+		case "releasedto":
+			if c, ok := compareDates(song.ReleaseDate, v); !ok || c > 0 {
+				return false
+			}
+		case "language":
+			if song.Language != v {
+				return false
+			}
+		case "text":
+			if !matchText(song.Text, v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compareDates orders two DD.MM.YYYY dates. Like a NULL in SQL, a date
+// that does not parse compares to nothing.
+func compareDates(a, b string) (int, bool) {
+	ta, err := time.Parse("02.01.2006", a)
+	if err != nil {
+		return 0, false
+	}
+	tb, err := time.Parse("02.01.2006", b)
+	if err != nil {
+		return 0, false
+	}
+	return ta.Compare(tb), true
+}
+
+// similarity averages the trigram similarity of the song to the names in
+// the filter.
+func similarity(song models.Song, filter map[string]string) float64 {
+	var sum float64
+	n := 0
+	if v, ok := filter["group"]; ok {
+		sum += fuzzy.Similarity(song.Group, v)
+		n++
+	}
+	if v, ok := filter["song"]; ok {
+		sum += fuzzy.Similarity(song.Song, v)
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// matchText stands in for full-text search: every word of the query has to
+// occur in the lyrics, ignoring case and punctuation.
+func matchText(text, query string) bool {
+	words := make(map[string]struct{})
+	for _, w := range tokenize(text) {
+		words[w] = struct{}{}
+	}
+
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return false
+	}
+	for _, t := range terms {
+		if _, ok := words[t]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// rank stands in for ts_rank: the share of the words of the lyrics that
+// are words of the query.
+func rank(text, query string) float64 {
+	terms := make(map[string]struct{})
+	for _, t := range tokenize(query) {
+		terms[t] = struct{}{}
+	}
+
+	words := tokenize(text)
+	if len(words) == 0 {
+		return 0
+	}
+	n := 0
+	for _, w := range words {
+		if _, ok := terms[w]; ok {
+			n++
+		}
+	}
+	return float64(n) / float64(len(words))
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+func id(song models.Song) uint64 {
+	n, _ := strconv.ParseUint(song.ID, 10, 64)
+	return n
+}
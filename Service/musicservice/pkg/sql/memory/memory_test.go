@@ -0,0 +1,151 @@
+package memory_test
+
+import (
+	"client"
+	"reflect"
+	"testing"
+
+	"musicservice/interal/app"
+	"musicservice/interal/models"
+	"musicservice/pkg/sql/memory"
+)
+
+var _ app.SongStore = (*memory.Memory)(nil)
+
+type seed struct {
+	group, song, date, text, language string
+}
+
+var songs = []seed{
+	{"Muse", "Hysteria", "01.12.2003", "It's bugging me\n\nGrating me", "english"},
+	{"Muse", "Supermassive Black Hole", "16.07.2006", "Ooh baby, don't you know I suffer?", "english"},
+	{"Queen", "Bohemian Rhapsody", "31.10.1975", "Is this the real life?", "english"},
+	{"Rammstein", "Sonne", "08.01.2001", "Hier kommt die Sonne", "german"},
+	{"Beyoncé", "Halo", "", "", "english"},
+}
+
+// newStore returns a store holding songs, with ids 1 to 5 in their order.
+func newStore(t *testing.T) *memory.Memory {
+	t.Helper()
+
+	m := memory.NewMemory()
+	for _, s := range songs {
+		id, err := m.QueueSong(models.NewSong{Group: s.group, Song: s.song, Language: s.language})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = m.CompleteEnrichment(id, client.SongDetail{ReleaseDate: s.date, Text: s.text, Link: "https://example.com/" + s.song}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func ids(songs []models.Song) []string {
+	ids := make([]string, 0, len(songs))
+	for _, s := range songs {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func TestGetSongsFilter(t *testing.T) {
+	m := newStore(t)
+
+	tests := []struct {
+		name   string
+		filter map[string]string
+		want   []string
+	}{
+		{"everything", map[string]string{}, []string{"1", "2", "3", "4", "5"}},
+		{"group", map[string]string{"group": "Muse"}, []string{"1", "2"}},
+		{"group is exact", map[string]string{"group": "muse"}, []string{}},
+		{"group and song", map[string]string{"group": "Muse", "song": "Hysteria"}, []string{"1"}},
+		{"link", map[string]string{"link": "https://example.com/Sonne"}, []string{"4"}},
+		{"release date", map[string]string{"releasedate": "16.07.2006"}, []string{"2"}},
+		{"released from", map[string]string{"releasedfrom": "01.01.2001"}, []string{"1", "2", "4"}},
+		{"released range", map[string]string{"releasedfrom": "01.01.1970", "releasedto": "31.12.2001"}, []string{"3", "4"}},
+		{"language", map[string]string{"language": "german"}, []string{"4"}},
+		{"text", map[string]string{"text": "sonne"}, []string{"4"}},
+		{"fuzzy", map[string]string{"match": "fuzzy", "threshold": "0.3", "group": "mus"}, []string{"1", "2"}},
+		{"fuzzy ignores accents", map[string]string{"match": "fuzzy", "threshold": "0.3", "group": "beyonce"}, []string{"5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := m.GetSongs(tt.filter, models.Page{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ids(got), tt.want) || total != len(tt.want) {
+				t.Errorf("got %v of %d, want %v", ids(got), total, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSongsRejects(t *testing.T) {
+	m := newStore(t)
+
+	for _, filter := range []map[string]string{
+		{"deleted_at": "x"},
+		{"match": "fuzzy", "group": "Muse", "threshold": "high"},
+	} {
+		if _, _, err := m.GetSongs(filter, models.Page{}); err == nil {
+			t.Errorf("filter %v was accepted", filter)
+		}
+	}
+}
+
+func TestGetSongsPage(t *testing.T) {
+	m := newStore(t)
+
+	tests := []struct {
+		name string
+		page models.Page
+		want []string
+	}{
+		{"limit", models.Page{Limit: 2}, []string{"1", "2"}},
+		{"offset", models.Page{Limit: 2, Offset: 2}, []string{"3", "4"}},
+		{"past the end", models.Page{Limit: 2, Offset: 10}, []string{}},
+		{"after", models.Page{Limit: 2, After: 3}, []string{"4", "5"}},
+		{"sort", models.Page{Order: []models.SortKey{{Field: models.SortGroup}, {Field: models.SortSong, Desc: true}}}, []string{"5", "2", "1", "3", "4"}},
+		{"undated last either way", models.Page{Order: []models.SortKey{{Field: models.SortReleaseDate, Desc: true}}}, []string{"2", "1", "4", "3", "5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := m.GetSongs(map[string]string{}, tt.page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ids(got), tt.want) {
+				t.Errorf("got %v, want %v", ids(got), tt.want)
+			}
+			if total != len(songs) {
+				t.Errorf("total = %d, want %d", total, len(songs))
+			}
+		})
+	}
+}
+
+func TestUpdateSong(t *testing.T) {
+	m := newStore(t)
+
+	if err := m.UpdateSong(1, map[string]string{"link": "https://example.com/new", "releasedate": ""}); err != nil {
+		t.Fatal(err)
+	}
+	song, err := m.GetSong(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.Link != "https://example.com/new" || song.ReleaseDate != "" {
+		t.Errorf("got %+v", song)
+	}
+
+	if err := m.UpdateSong(1, map[string]string{"id": "2"}); err == nil {
+		t.Error("unknown column was accepted")
+	}
+	if err := m.UpdateSong(42, map[string]string{"link": "x"}); err == nil {
+		t.Error("missing song was updated")
+	}
+}
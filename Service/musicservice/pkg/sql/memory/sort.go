@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"musicservice/interal/models"
+)
+
+// compareSongs orders two songs by the sort keys, then by id. Like NULLS
+// LAST in SQL, songs without a release date come last in either direction.
+func compareSongs(a, b models.Song, order []models.SortKey) int {
+	for _, k := range order {
+		var c int
+		switch k.Field {
+		case models.SortReleaseDate:
+			ta, errA := time.Parse("02.01.2006", a.ReleaseDate)
+			tb, errB := time.Parse("02.01.2006", b.ReleaseDate)
+			switch {
+			case errA != nil && errB != nil:
+			case errA != nil:
+				return 1
+			case errB != nil:
+				return -1
+			default:
+				c = ta.Compare(tb)
+			}
+		case models.SortGroup:
+			c = strings.Compare(a.Group, b.Group)
+		case models.SortSong:
+			c = strings.Compare(a.Song, b.Song)
+		case models.SortID:
+			c = cmp.Compare(id(a), id(b))
+		case models.SortRelevance:
+			c = cmp.Compare(a.Rank, b.Rank)
+		}
+
+		if k.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(id(a), id(b))
+}
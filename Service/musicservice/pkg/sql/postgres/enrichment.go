@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"client"
+	"database/sql"
+	"time"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/lang"
+)
+
+// QueueSong saves a song without its details together with the job that
+// enriches it. A song without a language gets the default one until the
+// language is detected from its lyrics.
+func (p *Postgres) QueueSong(song models.NewSong) (uint64, error) {
+	language := song.Language
+	if language == "" {
+		language = lang.Default
+	}
+
+	var id uint64
+	err := p.inTx(func(tx *sql.Tx) error {
+		err := saveGroup(tx, song.Group)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(`INSERT INTO songs("group", song, language) VALUES ($1, $2, $3::regconfig) RETURNING id;`,
+			song.Group, song.Song, language).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO song_enrichment (song_id, detect_language) VALUES ($1, $2);`, id, song.Language == "")
+		if err != nil {
+			return err
+		}
+
+		after, err := snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		return saveRevision(tx, id, models.ActionCreate, models.Song{}, after)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// ClaimEnrichments marks up to limit due jobs as running for lease and
+// returns them. A running job whose lease ran out is due again, so the jobs
+// of a worker that died are picked up by another; jobs claimed by a worker
+// running right now are skipped rather than waited for.
+func (p *Postgres) ClaimEnrichments(limit int, lease time.Duration) ([]models.EnrichmentJob, error) {
+	query := `UPDATE song_enrichment e
+		SET status = 'running', attempts = e.attempts + 1, run_at = now() + make_interval(secs => $2), updated_at = now()
+		FROM songs s
+		WHERE s.id = e.song_id AND e.song_id IN (
+			SELECT song_id FROM song_enrichment
+			JOIN songs ON songs.id = song_enrichment.song_id AND songs.deleted_at IS NULL
+			WHERE status IN ('pending', 'running') AND run_at <= now()
+			ORDER BY run_at
+			LIMIT $1
+			FOR UPDATE OF song_enrichment SKIP LOCKED)
+		RETURNING e.song_id, s."group", s.song, e.attempts, e.detect_language;`
+
+	rows, err := p.db.Query(query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.EnrichmentJob
+	for rows.Next() {
+		var job models.EnrichmentJob
+		if err := rows.Scan(&job.SongID, &job.Group, &job.Song, &job.Attempt, &job.DetectLanguage); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// CompleteEnrichment saves the details of a song and marks its job done.
+// An empty language keeps the one the song has.
+func (p *Postgres) CompleteEnrichment(id uint64, data client.SongDetail, language string) error {
+	return p.inTx(func(tx *sql.Tx) error {
+		before, err := snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+
+		query := `UPDATE songs SET releasedate = to_date(NULLIF($2, ''), 'DD.MM.YYYY'), text = $3, link = $4,
+			language = COALESCE(NULLIF($5, '')::regconfig, language)
+			WHERE id = $1 AND deleted_at IS NULL;`
+		res, err := tx.Exec(query, id, data.ReleaseDate, data.Text, data.Link, language)
+		if err != nil {
+			return err
+		}
+		if err := affected(res); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE song_enrichment SET status = 'done', last_error = NULL, updated_at = now() WHERE song_id = $1;`, id)
+		if err != nil {
+			return err
+		}
+
+		after, err := snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		return saveRevision(tx, id, models.ActionUpdate, before, after)
+	})
+}
+
+// FailEnrichment records why enriching a song failed. The job runs again at
+// retryAt, or is given up on when retryAt is zero.
+func (p *Postgres) FailEnrichment(id uint64, reason string, retryAt time.Time) error {
+	status, runAt := models.EnrichmentPending, sql.NullTime{Time: retryAt, Valid: true}
+	if retryAt.IsZero() {
+		status, runAt = models.EnrichmentFailed, sql.NullTime{}
+	}
+
+	query := `UPDATE song_enrichment SET status = $2, last_error = $3, run_at = COALESCE($4, now()), updated_at = now()
+		WHERE song_id = $1;`
+	res, err := p.db.Exec(query, id, status, reason, runAt)
+	if err != nil {
+		return err
+	}
+	return affected(res)
+}
+
+// Enrichment returns the enrichment status of a song. Songs created before
+// enrichment ran in the background have no job and count as done.
+func (p *Postgres) Enrichment(id uint64) (models.Enrichment, error) {
+	query := `SELECT songs.id, COALESCE(e.status, 'done'), COALESCE(e.attempts, 0), COALESCE(e.last_error, ''), e.run_at, e.updated_at
+		FROM songs LEFT JOIN song_enrichment e ON e.song_id = songs.id
+		WHERE songs.id = $1 AND songs.deleted_at IS NULL;`
+
+	var status models.Enrichment
+	var runAt, updatedAt sql.NullTime
+	err := p.db.QueryRow(query, id).Scan(&status.SongID, &status.Status, &status.Attempts, &status.LastError, &runAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return models.Enrichment{}, models.ErrSongNotFound
+	} else if err != nil {
+		return models.Enrichment{}, err
+	}
+
+	if status.Status == models.EnrichmentPending && runAt.Valid {
+		status.NextAttemptAt = &runAt.Time
+	}
+	if updatedAt.Valid {
+		status.UpdatedAt = &updatedAt.Time
+	}
+	return status, nil
+}
+
+// RetryEnrichment queues a failed enrichment again with a fresh count of
+// attempts.
+func (p *Postgres) RetryEnrichment(id uint64) error {
+	return p.inTx(func(tx *sql.Tx) error {
+		var status string
+		err := tx.QueryRow(`SELECT COALESCE(e.status, 'done') FROM songs LEFT JOIN song_enrichment e ON e.song_id = songs.id
+			WHERE songs.id = $1 AND songs.deleted_at IS NULL FOR UPDATE OF songs;`, id).Scan(&status)
+		if err == sql.ErrNoRows {
+			return models.ErrSongNotFound
+		} else if err != nil {
+			return err
+		}
+		if status != models.EnrichmentFailed {
+			return models.ErrEnrichmentNotFailed
+		}
+
+		_, err = tx.Exec(`UPDATE song_enrichment SET status = 'pending', attempts = 0, last_error = NULL, run_at = now(), updated_at = now()
+			WHERE song_id = $1;`, id)
+		return err
+	})
+}
@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	"musicservice/interal/models"
+)
+
+// newStore returns a store on the database of POSTGRES_TEST_URL, emptied
+// and migrated afresh, and skips the test when it is not set. The database
+// must be a throwaway one: everything in it is dropped.
+func newStore(t *testing.T) *Postgres {
+	t.Helper()
+
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if url == "" {
+		t.Skip("POSTGRES_TEST_URL is not set")
+	}
+
+	migrations, err := filepath.Abs(filepath.Join("..", "..", "..", "..", "..", "migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New("file://"+migrations, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Drop(); err != nil {
+		t.Fatal(err)
+	}
+	m, err = migrate.New("file://"+migrations, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &Postgres{db: db}
+}
+
+func TestClaimEnrichmentsConcurrently(t *testing.T) {
+	p := newStore(t)
+
+	const songs, workers = 40, 8
+	for i := range songs {
+		if _, err := p.QueueSong(models.NewSong{Group: "Muse", Song: "Song " + string(rune('A'+i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	claimed := make(map[uint64]int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				jobs, err := p.ClaimEnrichments(1, time.Minute)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if len(jobs) == 0 {
+					return
+				}
+				mu.Lock()
+				claimed[jobs[0].SongID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(claimed) != songs {
+		t.Errorf("claimed %d jobs, want %d", len(claimed), songs)
+	}
+	for id, n := range claimed {
+		if n != 1 {
+			t.Errorf("job %d claimed %d times", id, n)
+		}
+	}
+}
+
+func TestClaimExpiredLease(t *testing.T) {
+	p := newStore(t)
+	id, err := p.QueueSong(models.NewSong{Group: "Muse", Song: "Hysteria"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if jobs, err := p.ClaimEnrichments(1, 100*time.Millisecond); err != nil || len(jobs) != 1 {
+		t.Fatalf("claimed %d jobs: %v", len(jobs), err)
+	}
+	if jobs, _ := p.ClaimEnrichments(1, time.Minute); len(jobs) != 0 {
+		t.Fatalf("got %+v, want the running job to stay claimed", jobs)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	jobs, err := p.ClaimEnrichments(1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].SongID != id || jobs[0].Attempt != 2 {
+		t.Fatalf("got %+v, want the job of the dead worker as attempt 2", jobs)
+	}
+}
+
+func TestFailEnrichmentGivesUp(t *testing.T) {
+	p := newStore(t)
+	id, err := p.QueueSong(models.NewSong{Group: "Muse", Song: "Hysteria"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ClaimEnrichments(1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.FailEnrichment(id, "gone", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, _ := p.ClaimEnrichments(1, time.Minute); len(jobs) != 0 {
+		t.Fatalf("got %+v, want a failed job left alone", jobs)
+	}
+	status, err := p.Enrichment(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != models.EnrichmentFailed || status.LastError != "gone" {
+		t.Errorf("got %+v, want a failed job", status)
+	}
+}
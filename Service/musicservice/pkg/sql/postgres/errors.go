@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"musicservice/interal/models"
+)
+
+// domainError translates the constraint violations a statement can run into
+// on user input to domain errors and passes everything else through.
+func domainError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code {
+	case "23505": // unique_violation of songs_group_song_key
+		return fmt.Errorf("%w: %s", models.ErrSongExists, pqErr.Detail)
+	case "22007", "22008": // invalid_datetime_format, datetime_field_overflow
+		return fmt.Errorf("%w: %s", models.ErrInvalidDate, pqErr.Message)
+	}
+	return err
+}
@@ -1,11 +1,10 @@
 package postgres
 
 import (
-	"client"
 	"database/sql"
 	"fmt"
 	"musicservice/interal/models"
-	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 )
@@ -34,28 +33,119 @@ func (p *Postgres) Close() error {
     return p.db.Close()
 }
 
-func (p *Postgres) GetSongs(filter map[string]string) ([]models.Song ,error) {
-    query := `SELECT songs.id, songs.group, songs.song, to_char(songs.releasedate, 'DD.MM.YYYY'), songs.text, songs.link FROM songs WHERE`
-    
-    for k, v := range filter {
-       if k == "releasedate" {
-            query += ` AND "` + k + `" = to_date('` + v + `', 'DD.MM.YYYY')`
-            continue
-       }
+// GetSongs returns the requested page of songs matching the filter along
+// with the number of matching songs across all pages.
+func (p *Postgres) GetSongs(filter map[string]string, page models.Page) ([]models.Song, int, error) {
+    var songs []models.Song
+    var total int
+    err := p.inTx(func(tx *sql.Tx) error {
+        err := setThreshold(tx, filter)
+        if err != nil {
+            return err
+        }
+
+        total, err = countSongs(tx, filter)
+        if err != nil {
+            return err
+        }
+
+        songs, err = getSongs(tx, filter, page)
+        return err
+    })
+    if err != nil {
+        return nil, 0, err
+    }
+    return songs, total, nil
+}
+
+// setThreshold sets the similarity threshold of a fuzzy filter for the
+// rest of the transaction. The % operator matches by the threshold of the
+// session, which is what lets it use the trigram indexes.
+func setThreshold(tx *sql.Tx, filter map[string]string) error {
+    threshold, ok := filter["threshold"]
+    if !ok {
+        return nil
+    }
+    _, err := tx.Exec(`SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, threshold)
+    return err
+}
+
+// ExportSongs calls fn with every song matching the filter in id order,
+// reading them from a database cursor one row at a time. Lyrics are left
+// out unless withText is set.
+func (p *Postgres) ExportSongs(filter map[string]string, withText bool, fn func(models.Song) error) error {
+    return p.inTx(func(tx *sql.Tx) error {
+        err := setThreshold(tx, filter)
+        if err != nil {
+            return err
+        }
+
+        text := `''`
+        if withText {
+            text = `COALESCE(songs.text, '')`
+        }
+        q := newQuery(`SELECT songs.id, songs."group", songs.song, COALESCE(to_char(songs.releasedate, 'DD.MM.YYYY'), ''), ` + text + `, COALESCE(songs.link, ''), songs.language FROM songs`)
+        conds, err := q.filter(filter)
+        if err != nil {
+            return err
+        }
+        q.where(append(conds, live)...).write(` ORDER BY songs.id`)
+
+        rows, err := tx.Query(q.String(), q.args...)
+        if err != nil {
+            return err
+        }
+        defer rows.Close()
 
-       if k == "text" {
-            query += ` AND ` + `make_tsvector(songs.text) @@ plainto_tsquery($$` + v + `$$)`
-            continue
-       }
+        for rows.Next() {
+            var song models.Song
+            err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Language)
+            if err != nil {
+                return err
+            }
+            if err := fn(song); err != nil {
+                return err
+            }
+        }
+        return rows.Err()
+    })
+}
+
+func getSongs(ex execer, filter map[string]string, page models.Page) ([]models.Song, error) {
+    q := newQuery(``)
+    var order []string
+    similarity := q.similarity(filter)
+    if similarity == "" {
+        similarity = `0`
+    } else {
+        order = append(order, `similarity DESC`)
+    }
+    rank := q.rank(filter)
+    if rank == "" {
+        rank = `0`
+    } else {
+        order = append(order, `rank DESC`)
+    }
+    if len(page.Order) > 0 {
+        var err error
+        order, err = orderBy(page.Order)
+        if err != nil {
+            return nil, err
+        }
+    }
+    q.write(`SELECT songs.id, songs.group, songs.song, COALESCE(to_char(songs.releasedate, 'DD.MM.YYYY'), ''), COALESCE(songs.text, ''), COALESCE(songs.link, ''), songs.language, ` + rank + ` AS rank, ` + similarity + ` AS similarity FROM songs`)
 
-        query += ` AND "` + k + `"`
-        query += ` = '` + v + `'`
+    conds, err := q.filter(filter)
+    if err != nil {
+        return nil, err
+    }
+    conds = append(conds, live)
+    if page.After > 0 {
+        conds = append(conds, `songs.id > ` + q.arg(page.After))
     }
-    query += ";"
-    
-    query = strings.Replace(query, "WHERE AND", "WHERE ", -1)
+    q.where(conds...).page(page, order...)
 
-    rows, err := p.db.Query(query)
+    rows, err := ex.Query(q.String(), q.args...)
     if err!= nil {
         return nil, err
     }
@@ -64,70 +154,210 @@ func (p *Postgres) GetSongs(filter map[string]string) ([]models.Song ,error) {
     songs := make([]models.Song, 0, 10)
     for rows.Next() {
         var song models.Song
-        err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link)
+        err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Language, &song.Rank, &song.Similarity)
         if err != nil {
             return nil, err
         }
         songs = append(songs, song)
     }
-    return songs, nil
+    return songs, rows.Err()
+}
+
+func countSongs(ex execer, filter map[string]string) (int, error) {
+    q := newQuery(`SELECT count(*) FROM songs`)
+    conds, err := q.filter(filter)
+    if err != nil {
+        return 0, err
+    }
+    q.where(append(conds, live)...)
+
+    var total int
+    err = ex.QueryRow(q.String(), q.args...).Scan(&total)
+    return total, err
+}
+
+// SongIDs returns the ids of the songs with the given title, restricted
+// to one group unless group is empty.
+func (p *Postgres) SongIDs(group, song string) ([]uint64, error) {
+    q := newQuery(`SELECT id FROM songs`)
+    conds := []string{live, `song = ` + q.arg(song)}
+    if group != "" {
+        conds = append(conds, `"group" = ` + q.arg(group))
+    }
+    q.where(conds...).write(` ORDER BY id`)
+
+    rows, err := p.db.Query(q.String(), q.args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var ids []uint64
+    for rows.Next() {
+        var id uint64
+        if err := rows.Scan(&id); err != nil {
+            return nil, err
+        }
+        ids = append(ids, id)
+    }
+    return ids, rows.Err()
 }
 
-func (p *Postgres) GetText(song string) ([]byte, error) {
-    query := `SELECT "text" FROM songs WHERE "song" = '` + song + `';`
+// GetSong returns the song with the given id unless it is in the trash.
+func (p *Postgres) GetSong(id uint64) (models.Song, error) {
+    query := `SELECT id, "group", song, COALESCE(to_char(releasedate, 'DD.MM.YYYY'), ''), COALESCE(text, ''), COALESCE(link, ''), language
+        FROM songs WHERE id = $1 AND deleted_at IS NULL;`
+
+    var song models.Song
+    err := p.db.QueryRow(query, id).Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Language)
+    if err == sql.ErrNoRows {
+        return models.Song{}, models.ErrSongNotFound
+    }
+    return song, err
+}
+
+func (p *Postgres) GetText(id uint64) ([]byte, error) {
+    query := `SELECT "text" FROM songs WHERE id = $1 AND deleted_at IS NULL;`
     var text []byte
-    err := p.db.QueryRow(query).Scan(&text)
+    err := p.db.QueryRow(query, id).Scan(&text)
     if err == sql.ErrNoRows {
-        return nil, fmt.Errorf("song not found")
+        return nil, models.ErrSongNotFound
     } else if err != nil {
         return nil, err
     }
     return text, nil
 }
 
-func (p *Postgres) UpdateSong(song map[string]string) error {
-    query := `UPDATE songs SET `
+func (p *Postgres) UpdateSong(id uint64, values map[string]string) error {
+    return p.inTx(func(tx *sql.Tx) error {
+        if group, ok := values["group"]; ok {
+            if err := saveGroup(tx, group); err != nil {
+                return err
+            }
+        }
+
+        before, err := snapshot(tx, id)
+        if err != nil {
+            return err
+        }
+
+        q := newQuery(`UPDATE songs`)
+        if err := q.set(values); err != nil {
+            return err
+        }
+        q.write(` WHERE id = ` + q.arg(id) + ` AND deleted_at IS NULL`)
+
+        res, err := tx.Exec(q.String(), q.args...)
+        if err != nil {
+            return err
+        }
+        if err := affected(res); err != nil {
+            return err
+        }
+
+        after, err := snapshot(tx, id)
+        if err != nil {
+            return err
+        }
+        return saveRevision(tx, id, models.ActionUpdate, before, after)
+    })
+}
+
+// DeleteSong moves a song to the trash.
+func (p *Postgres) DeleteSong(id uint64) error {
+    return p.trash(id, `UPDATE songs SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL;`, models.ActionDelete)
+}
+
+// TrashedSongs returns the requested page of trashed songs, most recently
+// deleted first, along with the number of songs in the trash.
+func (p *Postgres) TrashedSongs(page models.Page) ([]models.TrashedSong, int, error) {
+    var total int
+    err := p.db.QueryRow(`SELECT count(*) FROM songs WHERE deleted_at IS NOT NULL;`).Scan(&total)
+    if err != nil {
+        return nil, 0, err
+    }
 
-    for i, v := range song {
-        query += `, "` + i + `"`
-        query += " = '" + v + "' "
+    q := newQuery(`SELECT songs.id, songs.group, songs.song, COALESCE(to_char(songs.releasedate, 'DD.MM.YYYY'), ''), COALESCE(songs.text, ''), COALESCE(songs.link, ''), songs.language, songs.deleted_at FROM songs WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC`)
+    if page.Limit > 0 {
+        q.write(` LIMIT ` + q.arg(page.Limit))
+    }
+    if page.Offset > 0 {
+        q.write(` OFFSET ` + q.arg(page.Offset))
     }
 
-    query += ` WHERE "song" = '` + song["song"] + `';`
+    rows, err := p.db.Query(q.String(), q.args...)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
 
-    query = strings.Replace(query, "SET ,", "SET ", -1)
-    _, err := p.db.Exec(query)
-    return err
+    songs := make([]models.TrashedSong, 0, 10)
+    for rows.Next() {
+        var song models.TrashedSong
+        err := rows.Scan(&song.ID, &song.Group, &song.Song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Language, &song.DeletedAt)
+        if err != nil {
+            return nil, 0, err
+        }
+        songs = append(songs, song)
+    }
+    return songs, total, rows.Err()
 }
 
-func (p *Postgres) DeleteSong(song string) error {
-    query := `DELETE FROM songs WHERE "song" = '`+ song +`';`
-    _, err := p.db.Exec(query)
-    return err
+// RestoreSong takes a song out of the trash.
+func (p *Postgres) RestoreSong(id uint64) error {
+    return p.trash(id, `UPDATE songs SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL;`, models.ActionRestore)
 }
 
-func (p *Postgres) SaveGroup(songs string) error {
-    query := `INSERT INTO groups("group") VALUES ($1)
-        ON CONFLICT ("group") DO NOTHING;`
+// trash moves a song in or out of the trash with query and records the
+// move as a revision.
+func (p *Postgres) trash(id uint64, query, action string) error {
+    return p.inTx(func(tx *sql.Tx) error {
+        song, err := snapshot(tx, id)
+        if err != nil {
+            return err
+        }
 
-    _, err := p.db.Exec(query, songs)
-    return err
+        res, err := tx.Exec(query, id)
+        if err != nil {
+            return err
+        }
+        if err := affected(res); err != nil {
+            return err
+        }
+        return saveRevision(tx, id, action, song, song)
+    })
 }
 
-func (p *Postgres) SaveMusic(song models.NewSong, data client.SongDetail) (uint64, error) {
-    query := `INSERT INTO songs("group", "song", "releasedate", "text", "link")
-        VALUES ($1, $2, to_date($3, 'DD.MM.YYYY'), $4, $5)
-        RETURNING id;
-    `
-    err := p.SaveGroup(song.Group)
+// PurgeSongs permanently removes the songs trashed before the given time.
+func (p *Postgres) PurgeSongs(before time.Time) (int64, error) {
+    query := `DELETE FROM songs WHERE deleted_at < $1;`
+    res, err := p.db.Exec(query, before)
     if err != nil {
         return 0, err
     }
+    return res.RowsAffected()
+}
 
-    var id uint64
-    err = p.db.QueryRow(query, song.Group, song.Song, data.ReleaseDate, data.Text, data.Link).Scan(&id)
-    if err!= nil {
-        return 0, err
+// affected reports a missing song when a statement touched no rows.
+func affected(res sql.Result) error {
+    n, err := res.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if n == 0 {
+        return models.ErrSongNotFound
     }
-    return id, nil
-}
\ No newline at end of file
+    return nil
+}
+
+func (p *Postgres) SaveGroup(group string) error {
+    return saveGroup(p.db, group)
+}
+
+func saveGroup(ex execer, group string) error {
+    query := `INSERT INTO groups("group") VALUES ($1)
+        ON CONFLICT ("group") DO NOTHING;`
+
+    _, err := ex.Exec(query, group)
+    return err
+}
@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/lang"
+)
+
+// column renders an SQL expression around the placeholder of its value.
+type column func(ph string) string
+
+// live restricts a query to songs that are not in the trash.
+const live = `songs.deleted_at IS NULL`
+
+// tsvector is the indexed full-text document of a song, built with the
+// text search configuration of the song's own language.
+const tsvector = `to_tsvector(songs.language, COALESCE(songs.text, ''))`
+
+// fuzzyColumns are the filter keys a fuzzy search compares by trigram
+// similarity instead of equality.
+var fuzzyColumns = map[string]string{
+	"group": `songs."group"`,
+	"song":  `songs.song`,
+}
+
+// filterColumns is the whitelist of keys accepted by GetSongs besides the
+// search keys text, textlanguage, match and threshold.
+var filterColumns = map[string]column{
+	"group":        func(ph string) string { return `songs."group" = ` + ph },
+	"song":         func(ph string) string { return `songs.song = ` + ph },
+	"link":         func(ph string) string { return `songs.link = ` + ph },
+	"releasedate":  func(ph string) string { return `songs.releasedate = to_date(` + ph + `, 'DD.MM.YYYY')` },
+	"releasedfrom": func(ph string) string { return `songs.releasedate >= to_date(` + ph + `, 'DD.MM.YYYY')` },
+	"releasedto":   func(ph string) string { return `songs.releasedate <= to_date(` + ph + `, 'DD.MM.YYYY')` },
+	"language":     func(ph string) string { return `songs.language = ` + ph + `::regconfig` },
+}
+
+// updateColumns is the whitelist of keys UpdateSong may change. An empty
+// release date clears it.
+var updateColumns = map[string]column{
+	"group":       func(ph string) string { return `"group" = ` + ph },
+	"song":        func(ph string) string { return `song = ` + ph },
+	"link":        func(ph string) string { return `link = ` + ph },
+	"releasedate": func(ph string) string { return `releasedate = to_date(NULLIF(` + ph + `, ''), 'DD.MM.YYYY')` },
+	"text":        func(ph string) string { return `text = ` + ph },
+	"language":    func(ph string) string { return `language = ` + ph + `::regconfig` },
+}
+
+// query accumulates an SQL statement together with its positional
+// arguments, so user input only ever reaches the database as a parameter.
+type query struct {
+	sql  strings.Builder
+	args []any
+}
+
+func newQuery(sql string) *query {
+	q := &query{}
+	q.sql.WriteString(sql)
+	return q
+}
+
+// arg registers a value and returns its placeholder.
+func (q *query) arg(v any) string {
+	q.args = append(q.args, v)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *query) write(sql string) *query {
+	q.sql.WriteString(sql)
+	return q
+}
+
+// filter registers every filter value and returns the matching
+// conditions, to be joined by where. The text filter is parsed with the
+// configuration named by textlanguage. When match is fuzzy, group and song
+// match names within the session's similarity threshold.
+func (q *query) filter(filter map[string]string) ([]string, error) {
+	fuzzy := filter["match"] == "fuzzy"
+	columns := make(map[string]string, len(filter))
+	for k, v := range filter {
+		switch k {
+		case "text", "textlanguage", "match", "threshold":
+			continue
+		}
+		if _, ok := fuzzyColumns[k]; ok && fuzzy {
+			continue
+		}
+		columns[k] = v
+	}
+
+	conds, err := q.exprs(filterColumns, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if fuzzy {
+		for _, k := range fuzzyKeys(filter) {
+			conds = append(conds, `fold(`+fuzzyColumns[k]+`) % fold(`+q.arg(filter[k])+`)`)
+		}
+	}
+	if text, ok := filter["text"]; ok {
+		conds = append(conds, tsvector+` @@ `+q.tsquery(text, filter["textlanguage"]))
+	}
+	return conds, nil
+}
+
+// similarity returns how close a song is to the fuzzy names of the filter,
+// averaged over them, or an empty string when the search is not fuzzy.
+func (q *query) similarity(filter map[string]string) string {
+	if filter["match"] != "fuzzy" {
+		return ""
+	}
+
+	keys := fuzzyKeys(filter)
+	if len(keys) == 0 {
+		return ""
+	}
+	terms := make([]string, 0, len(keys))
+	for _, k := range keys {
+		terms = append(terms, `similarity(fold(`+fuzzyColumns[k]+`), fold(`+q.arg(filter[k])+`))`)
+	}
+	return `(` + strings.Join(terms, ` + `) + `) / ` + strconv.Itoa(len(terms))
+}
+
+// fuzzyKeys returns the fuzzy columns present in the filter, in a fixed
+// order.
+func fuzzyKeys(filter map[string]string) []string {
+	var keys []string
+	for _, k := range []string{"group", "song"} {
+		if _, ok := filter[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// rank returns the relevance of a song to the text filter, or an empty
+// string when there is no text filter to rank by.
+func (q *query) rank(filter map[string]string) string {
+	text, ok := filter["text"]
+	if !ok {
+		return ""
+	}
+	return `ts_rank(` + tsvector + `, ` + q.tsquery(text, filter["textlanguage"]) + `)`
+}
+
+func (q *query) tsquery(text, language string) string {
+	if language == "" {
+		language = lang.Default
+	}
+	return `plainto_tsquery(` + q.arg(language) + `::regconfig, ` + q.arg(text) + `)`
+}
+
+// sortColumns maps the fields songs can be sorted by to the expressions
+// they order by; rank is the relevance column of the select list.
+var sortColumns = map[string]string{
+	models.SortReleaseDate: `songs.releasedate`,
+	models.SortGroup:       `songs."group"`,
+	models.SortSong:        `songs.song`,
+	models.SortID:          `songs.id`,
+	models.SortRelevance:   `rank`,
+}
+
+// orderBy returns the ORDER BY terms of the sort keys. Songs without a
+// release date come last in either direction.
+func orderBy(keys []models.SortKey) ([]string, error) {
+	terms := make([]string, 0, len(keys))
+	for _, k := range keys {
+		col, ok := sortColumns[k.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", k.Field)
+		}
+		if k.Desc {
+			terms = append(terms, col+` DESC NULLS LAST`)
+		} else {
+			terms = append(terms, col+` ASC NULLS LAST`)
+		}
+	}
+	return terms, nil
+}
+
+// where appends a WHERE clause joining the conditions with AND.
+func (q *query) where(conds ...string) *query {
+	if len(conds) > 0 {
+		q.write(" WHERE " + strings.Join(conds, " AND "))
+	}
+	return q
+}
+
+// page orders the result set by the given keys, then by id, and cuts out
+// the requested window.
+func (q *query) page(page models.Page, order ...string) *query {
+	q.write(" ORDER BY " + strings.Join(append(order, "songs.id"), ", "))
+	if page.Limit > 0 {
+		q.write(" LIMIT " + q.arg(page.Limit))
+	}
+	if page.Offset > 0 {
+		q.write(" OFFSET " + q.arg(page.Offset))
+	}
+	return q
+}
+
+// set appends the SET list of an UPDATE statement.
+func (q *query) set(values map[string]string) error {
+	list, err := q.exprs(updateColumns, values)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return models.ErrNothingToUpdate
+	}
+	q.write(" SET " + strings.Join(list, ", "))
+	return nil
+}
+
+func (q *query) exprs(whitelist map[string]column, values map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if _, ok := whitelist[k]; !ok {
+			return nil, fmt.Errorf("unknown column %q", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	exprs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		exprs = append(exprs, whitelist[k](q.arg(values[k])))
+	}
+	return exprs, nil
+}
+
+func (q *query) String() string {
+	return q.sql.String()
+}
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"musicservice/interal/models"
+)
+
+// hostile are titles that break a query built by pasting values into SQL.
+var hostile = []string{
+	`Don't Stop`,
+	`'; DROP TABLE songs;--`,
+	`$$`,
+	`$1`,
+	`"group"`,
+	`\'; SELECT 1; --`,
+}
+
+func TestFilterBindsValues(t *testing.T) {
+	for _, v := range hostile {
+		t.Run(v, func(t *testing.T) {
+			q := newQuery(`SELECT songs.id FROM songs`)
+			conds, err := q.filter(map[string]string{"group": v, "song": v, "link": v})
+			if err != nil {
+				t.Fatal(err)
+			}
+			q.where(conds...)
+
+			want := `SELECT songs.id FROM songs WHERE songs."group" = $1 AND songs.link = $2 AND songs.song = $3`
+			if q.String() != want {
+				t.Errorf("sql = %q, want %q", q.String(), want)
+			}
+			if !reflect.DeepEqual(q.args, []any{v, v, v}) {
+				t.Errorf("args = %q, want the value three times", q.args)
+			}
+		})
+	}
+}
+
+func TestFilterTextAndFuzzy(t *testing.T) {
+	for _, v := range hostile {
+		t.Run(v, func(t *testing.T) {
+			q := newQuery(``)
+			conds, err := q.filter(map[string]string{"match": "fuzzy", "song": v, "text": v, "textlanguage": "english"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := []string{
+				`fold(songs.song) % fold($1)`,
+				tsvector + ` @@ plainto_tsquery($2::regconfig, $3)`,
+			}
+			if !reflect.DeepEqual(conds, want) {
+				t.Errorf("conds = %q, want %q", conds, want)
+			}
+			if !reflect.DeepEqual(q.args, []any{v, "english", v}) {
+				t.Errorf("args = %q", q.args)
+			}
+		})
+	}
+}
+
+func TestSetBindsValues(t *testing.T) {
+	for _, v := range hostile {
+		t.Run(v, func(t *testing.T) {
+			q := newQuery(`UPDATE songs`)
+			if err := q.set(map[string]string{"song": v, "text": v}); err != nil {
+				t.Fatal(err)
+			}
+			q.where(`id = ` + q.arg(uint64(7)))
+
+			want := `UPDATE songs SET song = $1, text = $2 WHERE id = $3`
+			if q.String() != want {
+				t.Errorf("sql = %q, want %q", q.String(), want)
+			}
+			if !reflect.DeepEqual(q.args, []any{v, v, uint64(7)}) {
+				t.Errorf("args = %q", q.args)
+			}
+		})
+	}
+}
+
+func TestUnknownColumnRejected(t *testing.T) {
+	columns := []string{`id`, `deleted_at`, `"group" = '' OR 1=1 --`, `text; DROP TABLE songs`}
+	for _, c := range columns {
+		t.Run(c, func(t *testing.T) {
+			q := newQuery(``)
+			if _, err := q.filter(map[string]string{c: "x"}); err == nil {
+				t.Error("filter accepted the column")
+			}
+			if err := q.set(map[string]string{c: "x"}); err == nil {
+				t.Error("set accepted the column")
+			}
+			if strings.Contains(q.String(), c) {
+				t.Errorf("sql %q contains the column", q.String())
+			}
+		})
+	}
+}
+
+func TestSetNothingToUpdate(t *testing.T) {
+	q := newQuery(`UPDATE songs`)
+	if err := q.set(map[string]string{}); !errors.Is(err, models.ErrNothingToUpdate) {
+		t.Errorf("err = %v, want %v", err, models.ErrNothingToUpdate)
+	}
+}
+
+func TestPage(t *testing.T) {
+	tests := []struct {
+		name  string
+		page  models.Page
+		order []string
+		sql   string
+		args  []any
+	}{
+		{"everything", models.Page{}, nil, ` ORDER BY songs.id`, nil},
+		{"limit", models.Page{Limit: 10}, nil, ` ORDER BY songs.id LIMIT $1`, []any{10}},
+		{"limit and offset", models.Page{Limit: 10, Offset: 20}, []string{`rank DESC`}, ` ORDER BY rank DESC, songs.id LIMIT $1 OFFSET $2`, []any{10, 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newQuery(``).page(tt.page, tt.order...)
+			if q.String() != tt.sql {
+				t.Errorf("sql = %q, want %q", q.String(), tt.sql)
+			}
+			if !reflect.DeepEqual(q.args, tt.args) {
+				t.Errorf("args = %v, want %v", q.args, tt.args)
+			}
+		})
+	}
+}
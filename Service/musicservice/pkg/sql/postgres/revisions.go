@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/lang"
+)
+
+// execer is the part of *sql.DB and *sql.Tx the store queries through.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// inTx runs fn in a transaction, committing it when fn succeeds. Constraint
+// violations come back as domain errors.
+func (p *Postgres) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return domainError(err)
+	}
+	return domainError(tx.Commit())
+}
+
+// snapshot reads the current state of a song, trashed or not, and locks
+// its row for the rest of the transaction.
+func snapshot(ex execer, id uint64) (models.Song, error) {
+	query := `SELECT id, "group", song, COALESCE(to_char(releasedate, 'DD.MM.YYYY'), ''), COALESCE(text, ''), COALESCE(link, ''), language
+		FROM songs WHERE id = $1 FOR UPDATE;`
+
+	var song models.Song
+	err := ex.QueryRow(query, id).Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Language)
+	if err == sql.ErrNoRows {
+		return models.Song{}, models.ErrSongNotFound
+	}
+	return song, err
+}
+
+// saveRevision records the state of a song after a change together with
+// the fields the change touched.
+func saveRevision(ex execer, id uint64, action string, before, after models.Song) error {
+	query := `INSERT INTO song_revisions (song_id, action, snapshot, changes)
+		VALUES ($1, $2, $3, $4);`
+
+	snap, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	changes, err := json.Marshal(before.Changes(after))
+	if err != nil {
+		return err
+	}
+
+	_, err = ex.Exec(query, id, action, snap, changes)
+	return err
+}
+
+// Revisions returns the history of a song, oldest first, empty for a song
+// with none. Songs that are missing or in the trash are not found.
+func (p *Postgres) Revisions(songID uint64) ([]models.Revision, error) {
+	var live bool
+	err := p.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1 AND deleted_at IS NULL);`, songID).Scan(&live)
+	if err != nil {
+		return nil, err
+	}
+	if !live {
+		return nil, models.ErrSongNotFound
+	}
+
+	query := `SELECT id, song_id, action, snapshot, changes, created_at
+		FROM song_revisions WHERE song_id = $1 ORDER BY id;`
+
+	rows, err := p.db.Query(query, songID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]models.Revision, 0, 10)
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// Revision returns one revision of a song.
+func (p *Postgres) Revision(songID, revisionID uint64) (models.Revision, error) {
+	return revision(p.db, songID, revisionID)
+}
+
+// RollbackSong restores the fields of a song to their values at the given
+// revision and records the rollback as a new revision.
+func (p *Postgres) RollbackSong(songID, revisionID uint64) error {
+	return p.inTx(func(tx *sql.Tx) error {
+		rev, err := revision(tx, songID, revisionID)
+		if err != nil {
+			return err
+		}
+
+		before, err := snapshot(tx, songID)
+		if err != nil {
+			return err
+		}
+
+		err = saveGroup(tx, rev.Snapshot.Group)
+		if err != nil {
+			return err
+		}
+
+		// Revisions recorded before songs had a language carry none.
+		language := rev.Snapshot.Language
+		if language == "" {
+			language = lang.Default
+		}
+
+		query := `UPDATE songs SET "group" = $1, song = $2, releasedate = to_date(NULLIF($3, ''), 'DD.MM.YYYY'), text = $4, link = $5, language = $6::regconfig
+			WHERE id = $7 AND deleted_at IS NULL;`
+		s := rev.Snapshot
+		res, err := tx.Exec(query, s.Group, s.Song, s.ReleaseDate, s.Text, s.Link, language, songID)
+		if err != nil {
+			return err
+		}
+		if err := affected(res); err != nil {
+			return err
+		}
+
+		after, err := snapshot(tx, songID)
+		if err != nil {
+			return err
+		}
+		return saveRevision(tx, songID, models.ActionRollback, before, after)
+	})
+}
+
+func revision(ex execer, songID, revisionID uint64) (models.Revision, error) {
+	query := `SELECT id, song_id, action, snapshot, changes, created_at
+		FROM song_revisions WHERE song_id = $1 AND id = $2;`
+
+	rev, err := scanRevision(ex.QueryRow(query, songID, revisionID))
+	if err == sql.ErrNoRows {
+		return models.Revision{}, models.ErrRevisionNotFound
+	}
+	return rev, err
+}
+
+func scanRevision(row interface{ Scan(dest ...any) error }) (models.Revision, error) {
+	var rev models.Revision
+	var snap, changes []byte
+	err := row.Scan(&rev.ID, &rev.SongID, &rev.Action, &snap, &changes, &rev.CreatedAt)
+	if err != nil {
+		return models.Revision{}, err
+	}
+
+	if err := json.Unmarshal(snap, &rev.Snapshot); err != nil {
+		return models.Revision{}, err
+	}
+	if err := json.Unmarshal(changes, &rev.Changes); err != nil {
+		return models.Revision{}, err
+	}
+	return rev, nil
+}
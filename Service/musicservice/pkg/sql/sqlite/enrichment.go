@@ -0,0 +1,201 @@
+package sqlite
+
+import (
+	"client"
+	"database/sql"
+	"strings"
+	"time"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/lang"
+)
+
+// QueueSong saves a song without its details together with the job that
+// enriches it. A song without a language gets the default one until the
+// language is detected from its lyrics.
+func (s *SQLite) QueueSong(song models.NewSong) (uint64, error) {
+	language := song.Language
+	if language == "" {
+		language = lang.Default
+	}
+
+	var id uint64
+	err := s.inTx(func(tx *sql.Tx) error {
+		err := saveGroup(tx, song.Group)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(`INSERT INTO songs("group", song, language) VALUES (?, ?, ?) RETURNING id;`,
+			song.Group, song.Song, language).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		_, err = tx.Exec(`INSERT INTO song_enrichment (song_id, detect_language, run_at, updated_at) VALUES (?, ?, ?, ?);`,
+			id, song.Language == "", now, now)
+		if err != nil {
+			return err
+		}
+
+		after, err := snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		return saveRevision(tx, id, models.ActionCreate, models.Song{}, after)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// ClaimEnrichments marks up to limit due jobs as running for lease and
+// returns them. A running job whose lease ran out is due again, so the jobs
+// of a worker that died are picked up by another.
+func (s *SQLite) ClaimEnrichments(limit int, lease time.Duration) ([]models.EnrichmentJob, error) {
+	var jobs []models.EnrichmentJob
+	err := s.inTx(func(tx *sql.Tx) error {
+		now := time.Now().UTC()
+		query := `SELECT e.song_id, songs."group", songs.song, e.attempts + 1, e.detect_language
+			FROM song_enrichment e JOIN songs ON songs.id = e.song_id AND songs.deleted_at IS NULL
+			WHERE e.status IN ('pending', 'running') AND e.run_at <= ?
+			ORDER BY e.run_at
+			LIMIT ?;`
+		rows, err := tx.Query(query, now, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var job models.EnrichmentJob
+			if err := rows.Scan(&job.SongID, &job.Group, &job.Song, &job.Attempt, &job.DetectLanguage); err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+
+		args := []any{now.Add(lease), now}
+		for _, job := range jobs {
+			args = append(args, job.SongID)
+		}
+		_, err = tx.Exec(`UPDATE song_enrichment SET status = 'running', attempts = attempts + 1, run_at = ?, updated_at = ?
+			WHERE song_id IN (?`+strings.Repeat(", ?", len(jobs)-1)+`);`, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// CompleteEnrichment saves the details of a song and marks its job done.
+// An empty language keeps the one the song has.
+func (s *SQLite) CompleteEnrichment(id uint64, data client.SongDetail, language string) error {
+	releaseDate, err := optionalDate(data.ReleaseDate)
+	if err != nil {
+		return err
+	}
+
+	return s.inTx(func(tx *sql.Tx) error {
+		before, err := snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+
+		query := `UPDATE songs SET releasedate = ?, text = ?, link = ?, language = COALESCE(NULLIF(?, ''), language)
+			WHERE id = ? AND deleted_at IS NULL;`
+		res, err := tx.Exec(query, releaseDate, data.Text, data.Link, language, id)
+		if err != nil {
+			return err
+		}
+		if err := affected(res); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE song_enrichment SET status = 'done', last_error = NULL, updated_at = ? WHERE song_id = ?;`,
+			time.Now().UTC(), id)
+		if err != nil {
+			return err
+		}
+
+		after, err := snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		return saveRevision(tx, id, models.ActionUpdate, before, after)
+	})
+}
+
+// FailEnrichment records why enriching a song failed. The job runs again at
+// retryAt, or is given up on when retryAt is zero.
+func (s *SQLite) FailEnrichment(id uint64, reason string, retryAt time.Time) error {
+	now := time.Now().UTC()
+	status, runAt := models.EnrichmentPending, retryAt.UTC()
+	if retryAt.IsZero() {
+		status, runAt = models.EnrichmentFailed, now
+	}
+
+	query := `UPDATE song_enrichment SET status = ?, last_error = ?, run_at = ?, updated_at = ? WHERE song_id = ?;`
+	res, err := s.db.Exec(query, status, reason, runAt, now, id)
+	if err != nil {
+		return err
+	}
+	return affected(res)
+}
+
+// Enrichment returns the enrichment status of a song. Songs created before
+// enrichment ran in the background have no job and count as done.
+func (s *SQLite) Enrichment(id uint64) (models.Enrichment, error) {
+	query := `SELECT songs.id, COALESCE(e.status, 'done'), COALESCE(e.attempts, 0), COALESCE(e.last_error, ''), e.run_at, e.updated_at
+		FROM songs LEFT JOIN song_enrichment e ON e.song_id = songs.id
+		WHERE songs.id = ? AND songs.deleted_at IS NULL;`
+
+	var status models.Enrichment
+	var runAt, updatedAt sql.NullTime
+	err := s.db.QueryRow(query, id).Scan(&status.SongID, &status.Status, &status.Attempts, &status.LastError, &runAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return models.Enrichment{}, models.ErrSongNotFound
+	} else if err != nil {
+		return models.Enrichment{}, err
+	}
+
+	if status.Status == models.EnrichmentPending && runAt.Valid {
+		status.NextAttemptAt = &runAt.Time
+	}
+	if updatedAt.Valid {
+		status.UpdatedAt = &updatedAt.Time
+	}
+	return status, nil
+}
+
+// RetryEnrichment queues a failed enrichment again with a fresh count of
+// attempts.
+func (s *SQLite) RetryEnrichment(id uint64) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		var status string
+		err := tx.QueryRow(`SELECT COALESCE(e.status, 'done') FROM songs LEFT JOIN song_enrichment e ON e.song_id = songs.id
+			WHERE songs.id = ? AND songs.deleted_at IS NULL;`, id).Scan(&status)
+		if err == sql.ErrNoRows {
+			return models.ErrSongNotFound
+		} else if err != nil {
+			return err
+		}
+		if status != models.EnrichmentFailed {
+			return models.ErrEnrichmentNotFailed
+		}
+
+		now := time.Now().UTC()
+		_, err = tx.Exec(`UPDATE song_enrichment SET status = 'pending', attempts = 0, last_error = NULL, run_at = ?, updated_at = ?
+			WHERE song_id = ?;`, now, now, id)
+		return err
+	})
+}
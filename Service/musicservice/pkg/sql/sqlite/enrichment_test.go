@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"musicservice/interal/models"
+)
+
+func queue(t *testing.T, s *SQLite, songs ...string) []uint64 {
+	t.Helper()
+
+	var ids []uint64
+	for _, song := range songs {
+		id, err := s.QueueSong(models.NewSong{Group: "Muse", Song: song})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func claim(t *testing.T, s *SQLite, limit int, lease time.Duration) []models.EnrichmentJob {
+	t.Helper()
+
+	jobs, err := s.ClaimEnrichments(limit, lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jobs
+}
+
+func TestClaimEnrichments(t *testing.T) {
+	s := newStore(t)
+	queue(t, s, "Hysteria", "Uprising", "Madness")
+
+	jobs := claim(t, s, 2, time.Minute)
+	if len(jobs) != 2 || jobs[0].Attempt != 1 || !jobs[0].DetectLanguage {
+		t.Fatalf("got %+v, want 2 first attempts", jobs)
+	}
+	jobs = claim(t, s, 2, time.Minute)
+	if len(jobs) != 1 || jobs[0].Song != "Madness" {
+		t.Fatalf("got %+v, want only the unclaimed job", jobs)
+	}
+	if jobs := claim(t, s, 2, time.Minute); len(jobs) != 0 {
+		t.Fatalf("got %+v, want running jobs to stay claimed", jobs)
+	}
+}
+
+func TestClaimExpiredLease(t *testing.T) {
+	s := newStore(t)
+	ids := queue(t, s, "Hysteria")
+
+	claim(t, s, 1, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	jobs := claim(t, s, 1, time.Minute)
+	if len(jobs) != 1 || jobs[0].SongID != ids[0] || jobs[0].Attempt != 2 {
+		t.Fatalf("got %+v, want the job of the dead worker as attempt 2", jobs)
+	}
+}
+
+func TestFailEnrichment(t *testing.T) {
+	s := newStore(t)
+	ids := queue(t, s, "Hysteria", "Uprising")
+	claim(t, s, 2, time.Minute)
+
+	if err := s.FailEnrichment(ids[0], "boom", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.FailEnrichment(ids[1], "gone", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if jobs := claim(t, s, 2, time.Minute); len(jobs) != 0 {
+		t.Fatalf("got %+v, want no due jobs", jobs)
+	}
+
+	retrying, err := s.Enrichment(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retrying.Status != models.EnrichmentPending || retrying.LastError != "boom" || retrying.NextAttemptAt == nil {
+		t.Errorf("got %+v, want a pending retry", retrying)
+	}
+	failed, err := s.Enrichment(ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed.Status != models.EnrichmentFailed || failed.Attempts != 1 || failed.NextAttemptAt != nil {
+		t.Errorf("got %+v, want a failed job", failed)
+	}
+
+	if err := s.RetryEnrichment(ids[0]); !errors.Is(err, models.ErrEnrichmentNotFailed) {
+		t.Errorf("err = %v, want %v", err, models.ErrEnrichmentNotFailed)
+	}
+	if err := s.RetryEnrichment(ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	jobs := claim(t, s, 2, time.Minute)
+	if len(jobs) != 1 || jobs[0].SongID != ids[1] || jobs[0].Attempt != 1 {
+		t.Fatalf("got %+v, want the retried job as a first attempt", jobs)
+	}
+}
+
+func TestClaimSkipsTrashedSongs(t *testing.T) {
+	s := newStore(t)
+	ids := queue(t, s, "Hysteria")
+
+	if err := s.DeleteSong(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if jobs := claim(t, s, 1, time.Minute); len(jobs) != 0 {
+		t.Fatalf("got %+v, want the job of a trashed song skipped", jobs)
+	}
+}
@@ -0,0 +1,20 @@
+package sqlite
+
+import (
+	"errors"
+
+	msqlite "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"musicservice/interal/models"
+)
+
+// domainError translates the constraint violations a statement can run into
+// on user input to domain errors and passes everything else through.
+func domainError(err error) error {
+	var sqliteErr *msqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return models.ErrSongExists
+	}
+	return err
+}
@@ -0,0 +1,27 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"musicservice/pkg/fuzzy"
+
+	msqlite "modernc.org/sqlite"
+)
+
+// SQLite has no pg_trgm, so the trigram similarity of fuzzy searches is
+// computed by a Go function. Nothing indexes it: a fuzzy search scans every
+// song, which is fine at the sizes SQLite is used for.
+func init() {
+	msqlite.MustRegisterDeterministicScalarFunction("similarity", 2, func(_ *msqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		a, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("similarity: expected text, got %T", args[0])
+		}
+		b, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("similarity: expected text, got %T", args[1])
+		}
+		return fuzzy.Similarity(a, b), nil
+	})
+}
@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"musicservice/interal/models"
+)
+
+// Groups returns the requested page of groups by name along with the
+// number of groups.
+func (s *SQLite) Groups(page models.Page) ([]models.Group, int, error) {
+	var total int
+	err := s.db.QueryRow(`SELECT count(*) FROM groups;`).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	q := newQuery(`SELECT groups."group", count(songs.id) FROM groups
+		LEFT JOIN songs ON songs."group" = groups."group" AND ` + live + `
+		GROUP BY groups."group" ORDER BY groups."group"`)
+	if page.Limit > 0 {
+		q.write(` LIMIT ` + q.arg(page.Limit) + ` OFFSET ` + q.arg(page.Offset))
+	}
+
+	rows, err := s.db.Query(q.String(), q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.Group, 0, 10)
+	for rows.Next() {
+		var group models.Group
+		if err := rows.Scan(&group.Name, &group.SongCount); err != nil {
+			return nil, 0, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, total, rows.Err()
+}
+
+// Group returns one group with the number of its songs.
+func (s *SQLite) Group(name string) (models.Group, error) {
+	query := `SELECT groups."group", count(songs.id) FROM groups
+		LEFT JOIN songs ON songs."group" = groups."group" AND ` + live + `
+		WHERE groups."group" = ? GROUP BY groups."group";`
+
+	var group models.Group
+	err := s.db.QueryRow(query, name).Scan(&group.Name, &group.SongCount)
+	if err == sql.ErrNoRows {
+		return models.Group{}, models.ErrGroupNotFound
+	}
+	return group, err
+}
+
+// RenameGroup gives a group a name no other group has, moving its songs,
+// trashed ones included, along.
+func (s *SQLite) RenameGroup(name, newName string) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		if err := lockGroup(tx, name); err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(`INSERT INTO groups("group") VALUES (?) ON CONFLICT ("group") DO NOTHING;`, newName)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return fmt.Errorf("%w: %q", models.ErrGroupExists, newName)
+		}
+
+		return moveGroup(tx, name, newName)
+	})
+}
+
+// MergeGroups moves the songs of a group into another existing group and
+// removes the emptied group.
+func (s *SQLite) MergeGroups(name, into string) error {
+	if name == into {
+		return models.Invalid("invalid_group", "cannot merge group %q into itself", name)
+	}
+
+	return s.inTx(func(tx *sql.Tx) error {
+		if err := lockGroup(tx, name); err != nil {
+			return err
+		}
+		if err := lockGroup(tx, into); err != nil {
+			return err
+		}
+
+		query := `SELECT a.song FROM songs a
+			JOIN songs b ON b.song = a.song AND b."group" = ? AND b.deleted_at IS NULL
+			WHERE a."group" = ? AND a.deleted_at IS NULL LIMIT 1;`
+		var song string
+		err := tx.QueryRow(query, into, name).Scan(&song)
+		if err == nil {
+			return fmt.Errorf("%w: %q is in both groups", models.ErrSongExists, song)
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+
+		return moveGroup(tx, name, into)
+	})
+}
+
+// DeleteGroup removes a group that has no songs, in the trash or not.
+func (s *SQLite) DeleteGroup(name string) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		if err := lockGroup(tx, name); err != nil {
+			return err
+		}
+
+		var n int
+		err := tx.QueryRow(`SELECT count(*) FROM songs WHERE "group" = ?;`, name).Scan(&n)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("%w: %q has %d", models.ErrGroupNotEmpty, name, n)
+		}
+
+		_, err = tx.Exec(`DELETE FROM groups WHERE "group" = ?;`, name)
+		return err
+	})
+}
+
+// lockGroup checks that a group exists. SQLite has no row locks; the
+// transaction holds the database lock once it writes.
+func lockGroup(ex execer, name string) error {
+	var found string
+	err := ex.QueryRow(`SELECT "group" FROM groups WHERE "group" = ?;`, name).Scan(&found)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %q", models.ErrGroupNotFound, name)
+	}
+	return err
+}
+
+// moveGroup moves every song of group from to group to, recording the move
+// in the history of each song, and removes group from.
+func moveGroup(tx *sql.Tx, from, to string) error {
+	rows, err := tx.Query(`SELECT id FROM songs WHERE "group" = ? ORDER BY id;`, from)
+	if err != nil {
+		return err
+	}
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		before, err := snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE songs SET "group" = ? WHERE id = ?;`, to, id)
+		if err != nil {
+			return err
+		}
+
+		after := before
+		after.Group = to
+		if err := saveRevision(tx, id, models.ActionUpdate, before, after); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`DELETE FROM groups WHERE "group" = ?;`, from)
+	return err
+}
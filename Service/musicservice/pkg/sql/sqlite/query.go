@@ -0,0 +1,275 @@
+package sqlite
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"musicservice/interal/models"
+)
+
+// column is an SQL expression with one placeholder and the conversion of
+// the API value into the value bound to it.
+type column struct {
+	expr  string
+	value func(v string) (any, error)
+}
+
+func raw(v string) (any, error) { return v, nil }
+
+func date(v string) (any, error) { return toDate(v) }
+
+func optionalDate(v string) (any, error) {
+	if v == "" {
+		return nil, nil
+	}
+	return toDate(v)
+}
+
+func match(v string) (any, error) { return matchQuery(v), nil }
+
+// live restricts a query to songs that are not in the trash.
+const live = `songs.deleted_at IS NULL`
+
+// fuzzyColumns are the filter keys a fuzzy search compares by trigram
+// similarity instead of equality.
+var fuzzyColumns = map[string]string{
+	"group": `songs."group"`,
+	"song":  `songs.song`,
+}
+
+// filterColumns is the whitelist of keys accepted by GetSongs besides the
+// search keys textlanguage, match and threshold. FTS5 tokenizes every song with the same porter stemmer, so
+// the language of the query text does not change the match and
+// textlanguage is accepted only for parity with the Postgres store.
+var filterColumns = map[string]column{
+	"group":        {`songs."group" = ?`, raw},
+	"song":         {`songs.song = ?`, raw},
+	"link":         {`songs.link = ?`, raw},
+	"releasedate":  {`songs.releasedate = ?`, date},
+	"releasedfrom": {`songs.releasedate >= ?`, date},
+	"releasedto":   {`songs.releasedate <= ?`, date},
+	"text":         {`songs.id IN (SELECT rowid FROM songs_fts WHERE songs_fts MATCH ?)`, match},
+	"language":     {`songs.language = ?`, raw},
+}
+
+// updateColumns is the whitelist of keys UpdateSong may change. An empty
+// release date clears it.
+var updateColumns = map[string]column{
+	"group":       {`"group" = ?`, raw},
+	"song":        {`song = ?`, raw},
+	"link":        {`link = ?`, raw},
+	"releasedate": {`releasedate = ?`, optionalDate},
+	"text":        {`text = ?`, raw},
+	"language":    {`language = ?`, raw},
+}
+
+// query accumulates an SQL statement together with its positional
+// arguments, so user input only ever reaches the database as a parameter.
+type query struct {
+	sql  strings.Builder
+	args []any
+}
+
+func newQuery(sql string) *query {
+	q := &query{}
+	q.sql.WriteString(sql)
+	return q
+}
+
+// arg registers a value and returns its placeholder.
+func (q *query) arg(v any) string {
+	q.args = append(q.args, v)
+	return "?"
+}
+
+func (q *query) write(sql string) *query {
+	q.sql.WriteString(sql)
+	return q
+}
+
+// filter registers every filter value and returns the matching
+// conditions, to be joined by where.
+func (q *query) filter(filter map[string]string) ([]string, error) {
+	fuzzy := filter["match"] == "fuzzy"
+	columns := make(map[string]string, len(filter))
+	for k, v := range filter {
+		switch k {
+		case "textlanguage", "match", "threshold":
+			continue
+		}
+		if _, ok := fuzzyColumns[k]; ok && fuzzy {
+			continue
+		}
+		columns[k] = v
+	}
+
+	conds, err := q.exprs(filterColumns, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if fuzzy {
+		threshold, err := strconv.ParseFloat(filter["threshold"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %q: %w", filter["threshold"], err)
+		}
+		for _, k := range fuzzyKeys(filter) {
+			conds = append(conds, `similarity(`+fuzzyColumns[k]+`, `+q.arg(filter[k])+`) >= `+q.arg(threshold))
+		}
+	}
+	return conds, nil
+}
+
+// similarity returns how close a song is to the fuzzy names of the filter,
+// averaged over them, or an empty string when the search is not fuzzy.
+func (q *query) similarity(filter map[string]string) string {
+	if filter["match"] != "fuzzy" {
+		return ""
+	}
+
+	keys := fuzzyKeys(filter)
+	if len(keys) == 0 {
+		return ""
+	}
+	terms := make([]string, 0, len(keys))
+	for _, k := range keys {
+		terms = append(terms, `similarity(`+fuzzyColumns[k]+`, `+q.arg(filter[k])+`)`)
+	}
+	return `(` + strings.Join(terms, ` + `) + `) / ` + strconv.Itoa(len(terms))
+}
+
+// fuzzyKeys returns the fuzzy columns present in the filter, in a fixed
+// order.
+func fuzzyKeys(filter map[string]string) []string {
+	var keys []string
+	for _, k := range []string{"group", "song"} {
+		if _, ok := filter[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// rank returns the relevance of a song to the text filter, or an empty
+// string when there is no text filter to rank by. bm25 scores better
+// matches lower, so the score is negated to sort like ts_rank.
+func (q *query) rank(filter map[string]string) string {
+	text, ok := filter["text"]
+	if !ok {
+		return ""
+	}
+	return `(SELECT -bm25(songs_fts) FROM songs_fts WHERE songs_fts MATCH ` + q.arg(matchQuery(text)) + ` AND rowid = songs.id)`
+}
+
+// sortColumns maps the fields songs can be sorted by to the expressions
+// they order by; rank is the relevance column of the select list.
+var sortColumns = map[string]string{
+	models.SortReleaseDate: `songs.releasedate`,
+	models.SortGroup:       `songs."group"`,
+	models.SortSong:        `songs.song`,
+	models.SortID:          `songs.id`,
+	models.SortRelevance:   `rank`,
+}
+
+// orderBy returns the ORDER BY terms of the sort keys. Songs without a
+// release date come last in either direction.
+func orderBy(keys []models.SortKey) ([]string, error) {
+	terms := make([]string, 0, len(keys))
+	for _, k := range keys {
+		col, ok := sortColumns[k.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", k.Field)
+		}
+		if k.Desc {
+			terms = append(terms, col+` DESC NULLS LAST`)
+		} else {
+			terms = append(terms, col+` ASC NULLS LAST`)
+		}
+	}
+	return terms, nil
+}
+
+// where appends a WHERE clause joining the conditions with AND.
+func (q *query) where(conds ...string) *query {
+	if len(conds) > 0 {
+		q.write(" WHERE " + strings.Join(conds, " AND "))
+	}
+	return q
+}
+
+// page orders the result set by the given keys, then by id, and cuts out
+// the requested window.
+func (q *query) page(page models.Page, order ...string) *query {
+	q.write(" ORDER BY " + strings.Join(append(order, "songs.id"), ", "))
+	if page.Limit > 0 || page.Offset > 0 {
+		limit := page.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		q.write(" LIMIT " + q.arg(limit) + " OFFSET " + q.arg(page.Offset))
+	}
+	return q
+}
+
+// set appends the SET list of an UPDATE statement.
+func (q *query) set(values map[string]string) error {
+	list, err := q.exprs(updateColumns, values)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return models.ErrNothingToUpdate
+	}
+	q.write(" SET " + strings.Join(list, ", "))
+	return nil
+}
+
+func (q *query) exprs(whitelist map[string]column, values map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if _, ok := whitelist[k]; !ok {
+			return nil, fmt.Errorf("unknown column %q", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	exprs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		c := whitelist[k]
+		v, err := c.value(values[k])
+		if err != nil {
+			return nil, err
+		}
+		q.arg(v)
+		exprs = append(exprs, c.expr)
+	}
+	return exprs, nil
+}
+
+func (q *query) String() string {
+	return q.sql.String()
+}
+
+// toDate converts a DD.MM.YYYY date into the ISO form dates are stored in,
+// which keeps them sortable as text.
+func toDate(v string) (string, error) {
+	t, err := time.Parse("02.01.2006", v)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %v", models.ErrInvalidDate, v, err)
+	}
+	return t.Format(time.DateOnly), nil
+}
+
+// matchQuery turns plain text into an FTS5 query requiring every word, the
+// way plainto_tsquery does, so FTS5 operators in the input are not parsed.
+func matchQuery(text string) string {
+	words := strings.Fields(text)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
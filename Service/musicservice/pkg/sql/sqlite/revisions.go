@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"musicservice/interal/models"
+	"musicservice/pkg/lang"
+)
+
+// execer is the part of *sql.DB and *sql.Tx the store queries through.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// inTx runs fn in a transaction, committing it when fn succeeds. Constraint
+// violations come back as domain errors.
+func (s *SQLite) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return domainError(err)
+	}
+	return domainError(tx.Commit())
+}
+
+// snapshot reads the current state of a song, trashed or not.
+func snapshot(ex execer, id uint64) (models.Song, error) {
+	query := `SELECT id, "group", song, COALESCE(strftime('%d.%m.%Y', releasedate), ''), COALESCE(text, ''), COALESCE(link, ''), language
+		FROM songs WHERE id = ?;`
+
+	var song models.Song
+	err := ex.QueryRow(query, id).Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Language)
+	if err == sql.ErrNoRows {
+		return models.Song{}, models.ErrSongNotFound
+	}
+	return song, err
+}
+
+// saveRevision records the state of a song after a change together with
+// the fields the change touched.
+func saveRevision(ex execer, id uint64, action string, before, after models.Song) error {
+	query := `INSERT INTO song_revisions (song_id, action, snapshot, changes, created_at)
+		VALUES (?, ?, ?, ?, ?);`
+
+	snap, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	changes, err := json.Marshal(before.Changes(after))
+	if err != nil {
+		return err
+	}
+
+	_, err = ex.Exec(query, id, action, string(snap), string(changes), time.Now().UTC())
+	return err
+}
+
+// Revisions returns the history of a song, oldest first, empty for a song
+// with none. Songs that are missing or in the trash are not found.
+func (s *SQLite) Revisions(songID uint64) ([]models.Revision, error) {
+	var live bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM songs WHERE id = ? AND deleted_at IS NULL);`, songID).Scan(&live)
+	if err != nil {
+		return nil, err
+	}
+	if !live {
+		return nil, models.ErrSongNotFound
+	}
+
+	query := `SELECT id, song_id, action, snapshot, changes, created_at
+		FROM song_revisions WHERE song_id = ? ORDER BY id;`
+
+	rows, err := s.db.Query(query, songID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]models.Revision, 0, 10)
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// Revision returns one revision of a song.
+func (s *SQLite) Revision(songID, revisionID uint64) (models.Revision, error) {
+	return revision(s.db, songID, revisionID)
+}
+
+// RollbackSong restores the fields of a song to their values at the given
+// revision and records the rollback as a new revision.
+func (s *SQLite) RollbackSong(songID, revisionID uint64) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		rev, err := revision(tx, songID, revisionID)
+		if err != nil {
+			return err
+		}
+
+		before, err := snapshot(tx, songID)
+		if err != nil {
+			return err
+		}
+
+		err = saveGroup(tx, rev.Snapshot.Group)
+		if err != nil {
+			return err
+		}
+
+		var releaseDate any
+		if rev.Snapshot.ReleaseDate != "" {
+			releaseDate, err = toDate(rev.Snapshot.ReleaseDate)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Revisions recorded before songs had a language carry none.
+		language := rev.Snapshot.Language
+		if language == "" {
+			language = lang.Default
+		}
+
+		query := `UPDATE songs SET "group" = ?, song = ?, releasedate = ?, text = ?, link = ?, language = ?
+			WHERE id = ? AND deleted_at IS NULL;`
+		snap := rev.Snapshot
+		res, err := tx.Exec(query, snap.Group, snap.Song, releaseDate, snap.Text, snap.Link, language, songID)
+		if err != nil {
+			return err
+		}
+		if err := affected(res); err != nil {
+			return err
+		}
+
+		after, err := snapshot(tx, songID)
+		if err != nil {
+			return err
+		}
+		return saveRevision(tx, songID, models.ActionRollback, before, after)
+	})
+}
+
+func revision(ex execer, songID, revisionID uint64) (models.Revision, error) {
+	query := `SELECT id, song_id, action, snapshot, changes, created_at
+		FROM song_revisions WHERE song_id = ? AND id = ?;`
+
+	rev, err := scanRevision(ex.QueryRow(query, songID, revisionID))
+	if err == sql.ErrNoRows {
+		return models.Revision{}, models.ErrRevisionNotFound
+	}
+	return rev, err
+}
+
+func scanRevision(row interface{ Scan(dest ...any) error }) (models.Revision, error) {
+	var rev models.Revision
+	var snap, changes string
+	err := row.Scan(&rev.ID, &rev.SongID, &rev.Action, &snap, &changes, &rev.CreatedAt)
+	if err != nil {
+		return models.Revision{}, err
+	}
+
+	if err := json.Unmarshal([]byte(snap), &rev.Snapshot); err != nil {
+		return models.Revision{}, err
+	}
+	if err := json.Unmarshal([]byte(changes), &rev.Changes); err != nil {
+		return models.Revision{}, err
+	}
+	return rev, nil
+}
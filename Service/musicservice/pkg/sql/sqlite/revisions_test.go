@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"errors"
+	"testing"
+
+	"musicservice/interal/models"
+)
+
+func TestRevisionsBackfilled(t *testing.T) {
+	s, m := openStore(t)
+	// Stop before song_revisions exists, as a catalog from before it would.
+	if err := m.Steps(4); err != nil {
+		t.Fatal(err)
+	}
+	_, err := s.db.Exec(`INSERT INTO groups ("group") VALUES ('Muse');
+		INSERT INTO songs ("group", song, releasedate, text, link)
+		VALUES ('Muse', 'Hysteria', '2003-12-01', 'It''s bugging me', 'https://example.com');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := s.Revisions(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 || revisions[0].Action != models.ActionCreate {
+		t.Fatalf("got %+v, want one create revision", revisions)
+	}
+	want := models.Song{ID: "1", Group: "Muse", Song: "Hysteria", ReleaseDate: "01.12.2003", Text: "It's bugging me", Link: "https://example.com"}
+	if got := revisions[0].Snapshot; got != want {
+		t.Errorf("snapshot = %+v, want %+v", got, want)
+	}
+
+	// The backfilled revision is a baseline to roll back to.
+	err = s.UpdateSong(1, map[string]string{"text": "Changed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RollbackSong(1, revisions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	song, err := snapshot(s.db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.Text != "It's bugging me" || song.Language != "english" {
+		t.Errorf("got %+v, want the song as it was backfilled", song)
+	}
+}
+
+func TestRevisionsOfMissingSong(t *testing.T) {
+	s := newStore(t)
+	id := addSong(t, s, "Muse", "Hysteria", "It's bugging me")
+
+	if _, err := s.db.Exec(`DELETE FROM song_revisions WHERE song_id = ?;`, id); err != nil {
+		t.Fatal(err)
+	}
+	revisions, err := s.Revisions(id)
+	if err != nil || revisions == nil || len(revisions) != 0 {
+		t.Errorf("got %v, %v, want an empty history", revisions, err)
+	}
+
+	if _, err := s.Revisions(id + 1); !errors.Is(err, models.ErrSongNotFound) {
+		t.Errorf("missing song: err = %v, want %v", err, models.ErrSongNotFound)
+	}
+	if err := s.DeleteSong(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Revisions(id); !errors.Is(err, models.ErrSongNotFound) {
+		t.Errorf("trashed song: err = %v, want %v", err, models.ErrSongNotFound)
+	}
+}
@@ -0,0 +1,335 @@
+package sqlite
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"time"
+
+	"musicservice/interal/models"
+
+	_ "modernc.org/sqlite"
+)
+
+type SQLite struct {
+	db *sql.DB
+}
+
+func NewSQLite(path string) (*SQLite, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Ping()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// GetSongs returns the requested page of songs matching the filter along
+// with the number of matching songs across all pages.
+func (s *SQLite) GetSongs(filter map[string]string, page models.Page) ([]models.Song, int, error) {
+	total, err := s.countSongs(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The placeholders are positional, so the arguments of rank are
+	// registered before those of similarity, in the order the SELECT
+	// lists them.
+	q := newQuery(``)
+	rank := q.rank(filter)
+	similarity := q.similarity(filter)
+	var order []string
+	if similarity == "" {
+		similarity = `0`
+	} else {
+		order = append(order, `similarity DESC`)
+	}
+	if rank == "" {
+		rank = `0`
+	} else {
+		order = append(order, `rank DESC`)
+	}
+	if len(page.Order) > 0 {
+		order, err = orderBy(page.Order)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	q.write(`SELECT songs.id, songs."group", songs.song, COALESCE(strftime('%d.%m.%Y', songs.releasedate), ''), COALESCE(songs.text, ''), COALESCE(songs.link, ''), songs.language, ` + rank + ` AS rank, ` + similarity + ` AS similarity FROM songs`)
+
+	conds, err := q.filter(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	conds = append(conds, live)
+	if page.After > 0 {
+		conds = append(conds, `songs.id > `+q.arg(page.After))
+	}
+	q.where(conds...).page(page, order...)
+
+	rows, err := s.db.Query(q.String(), q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	songs := make([]models.Song, 0, 10)
+	for rows.Next() {
+		var song models.Song
+		err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Language, &song.Rank, &song.Similarity)
+		if err != nil {
+			return nil, 0, err
+		}
+		songs = append(songs, song)
+	}
+	return songs, total, rows.Err()
+}
+
+// ExportSongs calls fn with every song matching the filter in id order,
+// reading them from a database cursor one row at a time. Lyrics are left
+// out unless withText is set.
+func (s *SQLite) ExportSongs(filter map[string]string, withText bool, fn func(models.Song) error) error {
+	text := `''`
+	if withText {
+		text = `COALESCE(songs.text, '')`
+	}
+	q := newQuery(`SELECT songs.id, songs."group", songs.song, COALESCE(strftime('%d.%m.%Y', songs.releasedate), ''), ` + text + `, COALESCE(songs.link, ''), songs.language FROM songs`)
+	conds, err := q.filter(filter)
+	if err != nil {
+		return err
+	}
+	q.where(append(conds, live)...).write(` ORDER BY songs.id`)
+
+	rows, err := s.db.Query(q.String(), q.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var song models.Song
+		err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Language)
+		if err != nil {
+			return err
+		}
+		if err := fn(song); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *SQLite) countSongs(filter map[string]string) (int, error) {
+	q := newQuery(`SELECT count(*) FROM songs`)
+	conds, err := q.filter(filter)
+	if err != nil {
+		return 0, err
+	}
+	q.where(append(conds, live)...)
+
+	var total int
+	err = s.db.QueryRow(q.String(), q.args...).Scan(&total)
+	return total, err
+}
+
+// SongIDs returns the ids of the songs with the given title, restricted
+// to one group unless group is empty.
+func (s *SQLite) SongIDs(group, song string) ([]uint64, error) {
+	q := newQuery(`SELECT id FROM songs`)
+	conds := []string{live, `song = ` + q.arg(song)}
+	if group != "" {
+		conds = append(conds, `"group" = `+q.arg(group))
+	}
+	q.where(conds...).write(` ORDER BY id`)
+
+	rows, err := s.db.Query(q.String(), q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetSong returns the song with the given id unless it is in the trash.
+func (s *SQLite) GetSong(id uint64) (models.Song, error) {
+	query := `SELECT id, "group", song, COALESCE(strftime('%d.%m.%Y', releasedate), ''), COALESCE(text, ''), COALESCE(link, ''), language
+		FROM songs WHERE id = ? AND deleted_at IS NULL;`
+
+	var song models.Song
+	err := s.db.QueryRow(query, id).Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Language)
+	if err == sql.ErrNoRows {
+		return models.Song{}, models.ErrSongNotFound
+	}
+	return song, err
+}
+
+func (s *SQLite) GetText(id uint64) ([]byte, error) {
+	query := `SELECT text FROM songs WHERE id = ? AND deleted_at IS NULL;`
+	var text []byte
+	err := s.db.QueryRow(query, id).Scan(&text)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrSongNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return text, nil
+}
+
+func (s *SQLite) UpdateSong(id uint64, values map[string]string) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		if group, ok := values["group"]; ok {
+			if err := saveGroup(tx, group); err != nil {
+				return err
+			}
+		}
+
+		before, err := snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+
+		q := newQuery(`UPDATE songs`)
+		if err := q.set(values); err != nil {
+			return err
+		}
+		q.write(` WHERE id = ` + q.arg(id) + ` AND deleted_at IS NULL`)
+
+		res, err := tx.Exec(q.String(), q.args...)
+		if err != nil {
+			return err
+		}
+		if err := affected(res); err != nil {
+			return err
+		}
+
+		after, err := snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		return saveRevision(tx, id, models.ActionUpdate, before, after)
+	})
+}
+
+// DeleteSong moves a song to the trash.
+func (s *SQLite) DeleteSong(id uint64) error {
+	return s.trash(id, `UPDATE songs SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL;`, models.ActionDelete, time.Now().UTC(), id)
+}
+
+// TrashedSongs returns the requested page of trashed songs, most recently
+// deleted first, along with the number of songs in the trash.
+func (s *SQLite) TrashedSongs(page models.Page) ([]models.TrashedSong, int, error) {
+	var total int
+	err := s.db.QueryRow(`SELECT count(*) FROM songs WHERE deleted_at IS NOT NULL;`).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	q := newQuery(`SELECT songs.id, songs."group", songs.song, COALESCE(strftime('%d.%m.%Y', songs.releasedate), ''), COALESCE(songs.text, ''), COALESCE(songs.link, ''), songs.language, songs.deleted_at FROM songs WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC`)
+	if page.Limit > 0 || page.Offset > 0 {
+		limit := page.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		q.write(` LIMIT ` + q.arg(limit) + ` OFFSET ` + q.arg(page.Offset))
+	}
+
+	rows, err := s.db.Query(q.String(), q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	songs := make([]models.TrashedSong, 0, 10)
+	for rows.Next() {
+		var song models.TrashedSong
+		err := rows.Scan(&song.ID, &song.Group, &song.Song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.Language, &song.DeletedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		songs = append(songs, song)
+	}
+	return songs, total, rows.Err()
+}
+
+// RestoreSong takes a song out of the trash.
+func (s *SQLite) RestoreSong(id uint64) error {
+	return s.trash(id, `UPDATE songs SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL;`, models.ActionRestore, id)
+}
+
+// trash moves a song in or out of the trash with query and records the
+// move as a revision.
+func (s *SQLite) trash(id uint64, query, action string, args ...any) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		song, err := snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+		if err := affected(res); err != nil {
+			return err
+		}
+		return saveRevision(tx, id, action, song, song)
+	})
+}
+
+// PurgeSongs permanently removes the songs trashed before the given time.
+func (s *SQLite) PurgeSongs(before time.Time) (int64, error) {
+	query := `DELETE FROM songs WHERE deleted_at < ?;`
+	res, err := s.db.Exec(query, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// affected reports a missing song when a statement touched no rows.
+func affected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrSongNotFound
+	}
+	return nil
+}
+
+func (s *SQLite) SaveGroup(group string) error {
+	return saveGroup(s.db, group)
+}
+
+func saveGroup(ex execer, group string) error {
+	query := `INSERT INTO groups("group") VALUES (?)
+		ON CONFLICT ("group") DO NOTHING;`
+
+	_, err := ex.Exec(query, group)
+	return err
+}
+
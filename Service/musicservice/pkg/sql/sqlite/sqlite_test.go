@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"client"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	"musicservice/interal/models"
+)
+
+// newStore returns a store on a fresh, fully migrated database.
+func newStore(t *testing.T) *SQLite {
+	t.Helper()
+
+	s, m := openStore(t)
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// openStore returns a store on a fresh database with the migrations to run
+// on it, none of which has run yet.
+func openStore(t *testing.T) (*SQLite, *migrate.Migrate) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "music.db")
+	s, err := NewSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	migrations, err := filepath.Abs(filepath.Join("..", "..", "..", "..", "..", "migrations", "sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New("file://"+migrations, "sqlite://"+path+"?x-migrations-table=songs_migr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, m
+}
+
+// addSong saves a song with its details as enrichment would.
+func addSong(t *testing.T, s *SQLite, group, song, text string) uint64 {
+	t.Helper()
+
+	id, err := s.QueueSong(models.NewSong{Group: group, Song: song})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.CompleteEnrichment(id, client.SongDetail{ReleaseDate: "16.07.2006", Text: text, Link: "https://example.com"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestGetSongsFuzzyAndText(t *testing.T) {
+	s := newStore(t)
+	addSong(t, s, "Muse", "Supermassive Black Hole", "You set my soul alight")
+	addSong(t, s, "Muse", "Supermassive Black Hole Remix", "Nothing to see here")
+	addSong(t, s, "Queen", "Bohemian Rhapsody", "You set my soul alight")
+
+	tests := []struct {
+		name   string
+		filter map[string]string
+		want   []string
+	}{
+		{"fuzzy", map[string]string{"match": "fuzzy", "threshold": "0.3", "song": "supermasive black hol"}, []string{"Supermassive Black Hole", "Supermassive Black Hole Remix"}},
+		{"text", map[string]string{"text": "alight"}, []string{"Supermassive Black Hole", "Bohemian Rhapsody"}},
+		{"fuzzy and text", map[string]string{"match": "fuzzy", "threshold": "0.3", "song": "supermasive black hol", "text": "alight"}, []string{"Supermassive Black Hole"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs, total, err := s.GetSongs(tt.filter, models.Page{Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != len(tt.want) || len(songs) != len(tt.want) {
+				t.Fatalf("got %d songs of %d, want %d", len(songs), total, len(tt.want))
+			}
+			for _, song := range songs {
+				if !slices.Contains(tt.want, song.Song) {
+					t.Errorf("unexpected song %q", song.Song)
+				}
+			}
+		})
+	}
+}
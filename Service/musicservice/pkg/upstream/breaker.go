@@ -0,0 +1,114 @@
+package upstream
+
+import (
+	"sync"
+	"time"
+)
+
+// States of a Breaker.
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// Breaker is a circuit breaker. It opens after a run of failed calls and
+// fails every call fast until the cooldown is over; then one probe call is
+// let through, which closes the breaker when it succeeds and opens it again
+// when it fails.
+type Breaker struct {
+	failures int
+	cooldown time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failed   int
+	openedAt time.Time
+	probing  bool
+	onChange func(from, to string)
+}
+
+// NewBreaker returns a closed breaker that opens after failures failed calls
+// in a row and stays open for cooldown. A breaker with no failures never
+// opens.
+func NewBreaker(failures int, cooldown time.Duration) *Breaker {
+	return &Breaker{failures: failures, cooldown: cooldown, state: StateClosed}
+}
+
+// OnChange registers a function called with every change of state.
+func (b *Breaker) OnChange(fn func(from, to string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onChange = fn
+}
+
+// State returns the state of the breaker.
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may go through. A call that was allowed must
+// be followed by Done with its outcome.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.set(StateHalfOpen)
+		fallthrough
+	case StateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// Done records the outcome of a call Allow let through.
+func (b *Breaker) Done(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen {
+		b.probing = false
+		if ok {
+			b.failed = 0
+			b.set(StateClosed)
+		} else {
+			b.open()
+		}
+		return
+	}
+
+	if ok {
+		b.failed = 0
+		return
+	}
+	b.failed++
+	if b.failures > 0 && b.failed >= b.failures && b.state == StateClosed {
+		b.open()
+	}
+}
+
+func (b *Breaker) open() {
+	b.openedAt = time.Now()
+	b.set(StateOpen)
+}
+
+func (b *Breaker) set(state string) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(from, state)
+	}
+}
@@ -0,0 +1,74 @@
+package upstream
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBreakerStates(t *testing.T) {
+	b := NewBreaker(2, 30*time.Millisecond)
+	var changes []string
+	b.OnChange(func(from, to string) { changes = append(changes, from+">"+to) })
+
+	call := func(ok bool) bool {
+		if !b.Allow() {
+			return false
+		}
+		b.Done(ok)
+		return true
+	}
+
+	call(false)
+	call(true)
+	call(false)
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want a success to reset the run of failures", b.State())
+	}
+
+	call(false)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s after 2 failures in a row, want %s", b.State(), StateOpen)
+	}
+	if call(true) {
+		t.Fatal("open breaker let a call through")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("breaker did not let a probe through after the cooldown")
+	}
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s while probing, want %s", b.State(), StateHalfOpen)
+	}
+	if b.Allow() {
+		t.Fatal("half-open breaker let a second call through")
+	}
+	b.Done(false)
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s after a failed probe, want %s", b.State(), StateOpen)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if !call(true) {
+		t.Fatal("breaker did not let a probe through after the cooldown")
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s after a successful probe, want %s", b.State(), StateClosed)
+	}
+
+	want := []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %q, want %q", changes, want)
+	}
+}
+
+func TestBreakerWithoutFailuresNeverOpens(t *testing.T) {
+	b := NewBreaker(0, time.Minute)
+	for range 10 {
+		if !b.Allow() {
+			t.Fatal("breaker opened")
+		}
+		b.Done(false)
+	}
+}
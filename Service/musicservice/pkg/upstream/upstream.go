@@ -0,0 +1,163 @@
+// Package upstream makes calls to the song info service resilient: every
+// attempt has a timeout, failed attempts are retried with jittered backoff
+// and a circuit breaker fails calls fast while the service is down.
+package upstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the service while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Config sets how calls to the service are made.
+type Config struct {
+	// Timeout bounds each attempt, reading the response included.
+	Timeout time.Duration
+	// Retries is how many times a failed attempt is repeated.
+	Retries int
+	// Backoff is the longest wait before the first retry; it doubles with
+	// every retry up to MaxBackoff, and the actual wait is a random share
+	// of it so clients that failed together do not retry together.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// BreakerFailures is how many failed calls in a row open the breaker
+	// and BreakerCooldown how long it stays open.
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+// Client is an http.Client for the info service. It satisfies the request
+// doer of the generated API client.
+type Client struct {
+	http    *http.Client
+	config  Config
+	breaker *Breaker
+}
+
+// NewClient returns a client making calls with base as configured.
+func NewClient(base *http.Client, config Config) *Client {
+	return &Client{
+		http:    base,
+		config:  config,
+		breaker: NewBreaker(config.BreakerFailures, config.BreakerCooldown),
+	}
+}
+
+// Breaker returns the circuit breaker of the client.
+func (c *Client) Breaker() *Breaker {
+	return c.breaker
+}
+
+// Do sends a request, retrying idempotent ones that fail with a network
+// error or a 5xx status. A call counts once towards the breaker however
+// many attempts it took. The response of the last attempt is returned, so
+// a 5xx the retries could not get past still reaches the caller.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if !c.breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := c.do(req)
+	c.breaker.Done(err == nil && resp.StatusCode < http.StatusInternalServerError)
+	return resp, err
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	retries := c.config.Retries
+	if !idempotent(req) {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(req)
+		if attempt >= retries || !retryable(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleep(req.Context(), c.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends the request once within the timeout. The timeout keeps
+// running while the body is read and ends when it is closed.
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	if c.config.Timeout <= 0 {
+		return c.http.Do(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.config.Timeout)
+	resp, err := c.http.Do(req.Clone(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff returns a random wait of up to Backoff doubled attempt times,
+// capped at MaxBackoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.config.Backoff
+	for range attempt {
+		if c.config.MaxBackoff > 0 && d >= c.config.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	if c.config.MaxBackoff > 0 {
+		d = min(d, c.config.MaxBackoff)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d) + 1
+}
+
+func retryable(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// idempotent reports whether a request can be sent again without changing
+// its outcome.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return req.Body == nil || req.Body == http.NoBody
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,174 @@
+package upstream
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeUpstream answers with the statuses of script in turn, repeating the
+// last one, and counts the calls it gets. A status of 0 drops the
+// connection without answering.
+func fakeUpstream(t *testing.T, script ...int) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		status := script[min(n, len(script)-1)]
+		if status == 0 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func testConfig() Config {
+	return Config{Timeout: time.Second, Retries: 2, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		script []int
+		status int
+		failed bool
+		calls  int64
+	}{
+		{"5xx then success", http.MethodGet, []int{500, 503, 200}, 200, false, 3},
+		{"network error then success", http.MethodGet, []int{0, 200}, 200, false, 2},
+		{"retries run out", http.MethodGet, []int{500}, 500, false, 3},
+		{"network errors run out", http.MethodGet, []int{0}, 0, true, 3},
+		{"4xx is not retried", http.MethodGet, []int{404, 200}, 404, false, 1},
+		{"POST is not retried", http.MethodPost, []int{500, 200}, 500, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := fakeUpstream(t, tt.script...)
+			c := NewClient(srv.Client(), testConfig())
+
+			var body io.Reader
+			if tt.method == http.MethodPost {
+				body = strings.NewReader("{}")
+			}
+			req, _ := http.NewRequest(tt.method, srv.URL, body)
+			resp, err := c.Do(req)
+			if tt.failed {
+				if err == nil {
+					t.Fatalf("got status %d, want an error", resp.StatusCode)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != tt.status {
+					t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+				}
+			}
+			if calls.Load() != tt.calls {
+				t.Errorf("calls = %d, want %d", calls.Load(), tt.calls)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow-headers":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		case "/slow-body":
+			io.WriteString(w, "partial")
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	config := testConfig()
+	config.Timeout, config.Retries = 50*time.Millisecond, 0
+	c := NewClient(srv.Client(), config)
+
+	t.Run("before the response", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/slow-headers", nil)
+		start := time.Now()
+		if _, err := c.Do(req); err == nil {
+			t.Fatal("got a response, want a timeout")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("timed out after %v", elapsed)
+		}
+	})
+
+	t.Run("while reading the body", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/slow-body", nil)
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if _, err := io.ReadAll(resp.Body); err == nil {
+			t.Fatal("read the whole body, want a timeout")
+		}
+	})
+}
+
+func TestBreakerFailsFast(t *testing.T) {
+	srv, calls := fakeUpstream(t, 500, 500, 200)
+	config := testConfig()
+	config.Retries, config.BreakerFailures, config.BreakerCooldown = 0, 2, 50*time.Millisecond
+	c := NewClient(srv.Client(), config)
+
+	do := func() error {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		resp, err := c.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	do()
+	do()
+	if state := c.Breaker().State(); state != StateOpen {
+		t.Fatalf("state = %s after 2 failures, want %s", state, StateOpen)
+	}
+	if err := do(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want %v", err, ErrCircuitOpen)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d while open, want 2", calls.Load())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := do(); err != nil {
+		t.Fatal(err)
+	}
+	if state := c.Breaker().State(); state != StateClosed {
+		t.Errorf("state = %s after a successful probe, want %s", state, StateClosed)
+	}
+}
@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/create": {
             "post": {
-                "description": "create song from database",
+                "description": "create song from database; deprecated alias of POST /songs",
                 "consumes": [
                     "application/json"
                 ],
@@ -28,6 +28,7 @@ const docTemplate = `{
                     "create"
                 ],
                 "summary": "Create song",
+                "deprecated": true,
                 "parameters": [
                     {
                         "description": "song struct",
@@ -47,23 +48,32 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request error"
-                    },
-                    "404": {
-                        "description": "Not found error"
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     },
                     "405": {
                         "description": "Method not allowed"
                     },
+                    "409": {
+                        "description": "Conflict error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
                     "500": {
-                        "description": "Internal server error"
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     }
                 }
             }
         },
         "/delete": {
             "delete": {
-                "description": "delete song from database",
+                "description": "move song to the trash, see /trash and /songs/{id}/restore; deprecated alias of DELETE /songs/{id}",
                 "consumes": [
                     "application/json"
                 ],
@@ -74,13 +84,25 @@ const docTemplate = `{
                     "deleted"
                 ],
                 "summary": "Delete Song",
+                "deprecated": true,
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "song name",
                         "name": "song",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -88,162 +110,326 @@ const docTemplate = `{
                         "description": "success response"
                     },
                     "400": {
-                        "description": "Bad request error"
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     },
                     "404": {
-                        "description": "Not found error"
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     },
                     "405": {
                         "description": "Method not allowed"
                     },
                     "500": {
-                        "description": "Internal server error"
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     }
                 }
             }
         },
-        "/search": {
-            "post": {
-                "description": "get songs from database",
-                "consumes": [
-                    "application/json"
-                ],
+        "/diff": {
+            "get": {
+                "description": "compare two revisions of a song; deprecated alias of GET /songs/{id}/revisions/diff",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "data"
+                    "revisions"
                 ],
-                "summary": "Get Data",
+                "summary": "Revision diff",
+                "deprecated": true,
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "first page",
-                        "name": "page",
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "count page",
-                        "name": "limit",
+                        "type": "integer",
+                        "description": "revision id to compare from",
+                        "name": "from",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "description": "filter information",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.FilterSong"
-                        }
+                        "type": "integer",
+                        "description": "revision id to compare to",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Song"
-                            }
+                            "$ref": "#/definitions/models.RevisionDiff"
                         }
                     },
                     "400": {
-                        "description": "Bad request error"
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     },
                     "404": {
-                        "description": "Not found error"
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     },
                     "405": {
                         "description": "Method not allowed"
                     },
                     "500": {
-                        "description": "Internal server error"
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     }
                 }
             }
         },
-        "/text": {
-            "post": {
-                "description": "get text from database",
-                "consumes": [
+        "/groups": {
+            "get": {
+                "description": "list groups by name with the number of their songs outside the trash",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "List groups",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "groups per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "groups to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupsPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{name}": {
+            "get": {
+                "description": "get a group with one page of its songs",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "text"
+                    "groups"
                 ],
-                "summary": "Get Text",
+                "summary": "Get group",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "first page",
-                        "name": "page",
-                        "in": "query",
+                        "description": "group name",
+                        "name": "name",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "count page",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "description": "songs per page",
+                        "name": "size",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "song name",
-                        "name": "song",
-                        "in": "query",
-                        "required": true
+                        "description": "next cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/server.TextSong"
+                            "$ref": "#/definitions/models.GroupPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    }
+                }
+            },
+            "delete": {
+                "description": "remove a group that has no songs, in the trash or not",
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Delete group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "success response"
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "Conflict error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "rename a group and every song of it; the new name must not be taken",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Rename group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new name",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/server.GroupName"
                         }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "success response"
                     },
                     "400": {
-                        "description": "Bad request error"
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     },
                     "404": {
-                        "description": "Not found error"
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     },
                     "405": {
                         "description": "Method not allowed"
                     },
+                    "409": {
+                        "description": "Conflict error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
                     "500": {
-                        "description": "Internal server error"
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     }
                 }
             }
         },
-        "/update": {
+        "/groups/{name}/merge": {
             "post": {
-                "description": "update song from database",
+                "description": "move the songs of a group into another group and remove it; fails when both have a song with the same title",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "update"
+                    "groups"
                 ],
-                "summary": "Update song",
+                "summary": "Merge groups",
                 "parameters": [
                     {
-                        "description": "update song",
+                        "type": "string",
+                        "description": "group to merge",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "group to merge into",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.FilterSong"
+                            "$ref": "#/definitions/server.MergeInto"
                         }
                     }
                 ],
@@ -252,72 +438,2171 @@ const docTemplate = `{
                         "description": "success response"
                     },
                     "400": {
-                        "description": "Bad request error"
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     },
                     "404": {
-                        "description": "Not found error"
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     },
                     "405": {
                         "description": "Method not allowed"
                     },
+                    "409": {
+                        "description": "Conflict error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
                     "500": {
-                        "description": "Internal server error"
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.FilterSong": {
-            "description": "Filter song model info",
-            "type": "object",
-            "properties": {
-                "group": {
-                    "type": "string"
-                },
-                "link": {
-                    "type": "string"
-                },
-                "releaseDate": {
-                    "type": "string"
-                },
-                "song": {
-                    "type": "string"
-                },
-                "text": {
-                    "type": "string"
-                }
-            }
         },
-        "models.NewSong": {
-            "description": "Song information about user",
-            "type": "object",
-            "properties": {
-                "group": {
-                    "type": "string"
-                },
-                "song": {
-                    "type": "string"
+        "/info-cache": {
+            "get": {
+                "description": "get the hits, misses, evictions and hit rate of the cache in front of the song info service; hits include songs the service does not know and callers that waited for a call already being made",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "info cache"
+                ],
+                "summary": "Get info cache stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/infocache.Stats"
+                        }
+                    },
+                    "404": {
+                        "description": "Info cache is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    }
                 }
             }
         },
-        "models.Song": {
-            "description": "Song information about the account",
-            "type": "object",
-            "properties": {
-                "group": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "link": {
-                    "type": "string"
-                },
-                "releaseDate": {
-                    "type": "string"
-                },
-                "song": {
+        "/purge": {
+            "delete": {
+                "description": "permanently remove songs kept in the trash longer than the retention period",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deleted"
+                ],
+                "summary": "Purge trash",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.Purged"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/restore": {
+            "post": {
+                "description": "take song out of the trash; deprecated alias of POST /songs/{id}/restore",
+                "tags": [
+                    "deleted"
+                ],
+                "summary": "Restore song",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "success response"
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/resync": {
+            "get": {
+                "description": "get the report of the last resync of song details with the info service, scheduled or not",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "resync"
+                ],
+                "summary": "Get last resync",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResyncReport"
+                        }
+                    },
+                    "404": {
+                        "description": "No resync has run yet",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    }
+                }
+            },
+            "post": {
+                "description": "compare the link, release date and lyrics of every song with the info service and report the songs that differ; the differences are applied when apply is set and only recorded for review otherwise. Songs still being enriched are skipped and a run that stops early, when the info service is unavailable, the songs cannot be read or the request is cancelled, reports why in error",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "resync"
+                ],
+                "summary": "Resync songs",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "apply the changes instead of only reporting them",
+                        "name": "apply",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "songs read at a time",
+                        "name": "batch",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResyncReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "A resync is already running",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/revisions": {
+            "get": {
+                "description": "list the revisions of a song, oldest first; deprecated alias of GET /songs/{id}/revisions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Song revisions",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "song name",
+                        "name": "song",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Revision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/rollback": {
+            "post": {
+                "description": "restore the fields of a song to one of its revisions; deprecated alias of POST /songs/{id}/rollback",
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Roll back song",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision id",
+                        "name": "revision",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "success response"
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "post": {
+                "description": "get songs from database; deprecated alias of GET /songs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "data"
+                ],
+                "summary": "Get Data",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "songs per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "songs to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma-separated sort fields releaseDate, group, song, id and relevance, prefixed with - for descending, like -releaseDate,group",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page of the verses of each song's lyrics, counting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "verses of each song's lyrics per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "description": "filter information",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.FilterSong"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongsPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs": {
+            "get": {
+                "description": "get one page of the songs matching the filter query parameters",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "List songs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "exact release date, DD.MM.YYYY",
+                        "name": "releaseDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "released on or after, DD.MM.YYYY",
+                        "name": "releasedFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "released on or before, DD.MM.YYYY",
+                        "name": "releasedTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "release year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "release decade, like 1990",
+                        "name": "decade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "words of the lyrics",
+                        "name": "text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "language of text, detected when empty or auto",
+                        "name": "textLanguage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "song link",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "song language",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exact",
+                            "fuzzy"
+                        ],
+                        "type": "string",
+                        "description": "exact or fuzzy matching of group and song",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "similarity a fuzzy match needs",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "songs per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "songs to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma-separated sort fields releaseDate, group, song, id and relevance, prefixed with - for descending, like -releaseDate,group",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page of the verses of each song's lyrics, counting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "verses of each song's lyrics per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongsPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "create a song right away and queue it for enrichment, which fetches its release date, lyrics and link from the info service in the background; see /songs/{id}/enrichment for its progress",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Create song",
+                "parameters": [
+                    {
+                        "description": "song struct",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NewSong"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/server.NewID"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "path of the new song"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "Conflict error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/export": {
+            "get": {
+                "description": "stream every song matching the filter query parameters in id order as NDJSON, CSV or a JSON array, optionally with lyrics; songs are read from a database cursor and written as they come, so the response has no fixed length",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Export songs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "ndjson",
+                            "csv",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "export format, ndjson when empty",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include the lyrics of each song",
+                        "name": "lyrics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "exact release date, DD.MM.YYYY",
+                        "name": "releaseDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "released on or after, DD.MM.YYYY",
+                        "name": "releasedFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "released on or before, DD.MM.YYYY",
+                        "name": "releasedTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "release year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "release decade, like 1990",
+                        "name": "decade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "words of the lyrics",
+                        "name": "text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "language of text, detected when empty or auto",
+                        "name": "textLanguage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "song link",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "song language",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "exact",
+                            "fuzzy"
+                        ],
+                        "type": "string",
+                        "description": "exact or fuzzy matching of group and song",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "similarity a fuzzy match needs",
+                        "name": "threshold",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Song"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/import": {
+            "post": {
+                "description": "create many songs from a CSV file of group, song and optional language columns, or from NDJSON lines of new songs; every row is reported as created, duplicate or failed, and created songs are queued for enrichment",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Import songs",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "format of the body, taken from Content-Type when empty",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "description": "CSV or NDJSON rows",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "413": {
+                        "description": "Request too large",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported format",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}": {
+            "get": {
+                "description": "get one song with its full lyrics",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    }
+                }
+            },
+            "delete": {
+                "description": "move a song to the trash, see /trash and /songs/{id}/restore",
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Delete song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "success response"
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "apply a JSON merge patch to a song: fields left out stay as they are and null fields are cleared; the title can be changed as well, as long as the group has no other song with the new title",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Update song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "fields to change, null to clear",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongPatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "success response"
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "Conflict error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported media type",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/enrichment": {
+            "get": {
+                "description": "get how far fetching a song's details from the info service has got: pending or running while it is queued or being fetched, done once the details are saved and failed when the info service has no details or the attempts ran out",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get song enrichment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Enrichment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/enrichment/retry": {
+            "post": {
+                "description": "queue a song whose enrichment failed again, with a fresh count of attempts",
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Retry song enrichment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "enrichment queued",
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "path of the enrichment status"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "Enrichment has not failed",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/restore": {
+            "post": {
+                "description": "take a song out of the trash",
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Restore song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "success response"
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions": {
+            "get": {
+                "description": "list the revisions of a song, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Song revisions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Revision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions/diff": {
+            "get": {
+                "description": "compare two revisions of a song",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Revision diff",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision id to compare from",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision id to compare to",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevisionDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/rollback": {
+            "post": {
+                "description": "restore the fields of a song to one of its revisions",
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Roll back song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision id",
+                        "name": "revision",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "success response"
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/text": {
+            "get": {
+                "description": "get a page of the verses of the lyrics of a song, as JSON or, with format=text or Accept: text/plain, as plain text linking the next page",
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Get song text",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page of verses, counting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "verses per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "text"
+                        ],
+                        "type": "string",
+                        "description": "response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Lyrics"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/text/search": {
+            "get": {
+                "description": "find the lines of the lyrics of a song that contain a phrase, ignoring case and accents, grouped by verse with the character offsets of every occurrence and highlighted lines",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Search song text",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "phrase to find",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LyricsSearch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/text": {
+            "post": {
+                "description": "get one page of the verses of the lyrics as plain text in JSON; deprecated alias of GET /songs/{id}/text",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "text"
+                ],
+                "summary": "Get Text",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page of verses, counting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "verses per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "song name",
+                        "name": "song",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/server.TextSong"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "description": "list songs in the trash, most recently deleted first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deleted"
+                ],
+                "summary": "Trashed songs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "songs per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "songs to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrashPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/update": {
+            "post": {
+                "description": "update song from database; deprecated alias of PATCH /songs/{id}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "update"
+                ],
+                "summary": "Update song",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id, otherwise the song is looked up by its name in the body",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group of the song when looked up by name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "description": "update song",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.FilterSong"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "success response"
+                    },
+                    "400": {
+                        "description": "Bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method not allowed"
+                    },
+                    "409": {
+                        "description": "Conflict error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/server.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "infocache.Stats": {
+            "description": "Counters of the song info cache since the service started",
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "integer"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "hitRate": {
+                    "type": "number"
+                },
+                "hits": {
+                    "description": "Hits counts calls answered from the cache, NegativeHits the share\nof them answered with a song the service does not know.",
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "negativeHits": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Enrichment": {
+            "description": "Progress of fetching a song's release date, lyrics and link from the info service; a failed enrichment can be retried",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "songId": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "running",
+                        "done",
+                        "failed"
+                    ]
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FieldChange": {
+            "description": "Field value before and after a change",
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "new": {
+                    "type": "string"
+                },
+                "old": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FilterSong": {
+            "description": "Filter song model info",
+            "type": "object",
+            "properties": {
+                "decade": {
+                    "type": "integer",
+                    "example": 1990
+                },
+                "group": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "match": {
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "fuzzy"
+                    ]
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "releasedFrom": {
+                    "type": "string",
+                    "example": "01.01.2000"
+                },
+                "releasedTo": {
+                    "type": "string",
+                    "example": "31.12.2009"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "textLanguage": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "year": {
+                    "type": "integer",
+                    "example": 2006
+                }
+            }
+        },
+        "models.Group": {
+            "description": "Group with the number of its songs outside the trash",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "songCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.GroupPage": {
+            "description": "Group with one page of its songs",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "songCount": {
+                    "type": "integer"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                }
+            }
+        },
+        "models.GroupsPage": {
+            "description": "Page of groups with the total count",
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Group"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ImportReport": {
+            "description": "Per-row report of a bulk import with the count of each outcome",
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRow"
+                    }
+                }
+            }
+        },
+        "models.ImportRow": {
+            "description": "Outcome of one row of a bulk import; Row is the line or record number in the uploaded file",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "row": {
+                    "type": "integer"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "duplicate",
+                        "failed"
+                    ]
+                }
+            }
+        },
+        "models.LineMatch": {
+            "description": "Line containing the phrase, numbered from 1 within its verse, with the character offsets of each occurrence and the line, HTML-escaped, with the occurrences wrapped in \u003cmark\u003e tags",
+            "type": "object",
+            "properties": {
+                "highlight": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "spans": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Span"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Lyrics": {
+            "description": "Page of the verses of a song's lyrics with pagination metadata",
+            "type": "object",
+            "properties": {
+                "hasNext": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "songId": {
+                    "type": "integer"
+                },
+                "totalVerses": {
+                    "type": "integer"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Verse"
+                    }
+                }
+            }
+        },
+        "models.LyricsSearch": {
+            "description": "Verses of a song's lyrics that contain a phrase, in order",
+            "type": "object",
+            "properties": {
+                "query": {
+                    "type": "string"
+                },
+                "songId": {
+                    "type": "integer"
+                },
+                "totalMatches": {
+                    "type": "integer"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VerseMatch"
+                    }
+                }
+            }
+        },
+        "models.NewSong": {
+            "description": "Song information about user",
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResyncReport": {
+            "description": "Summary of comparing the details of every song with the info service; with Applied unset the changes were only recorded for review",
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean"
+                },
+                "changed": {
+                    "type": "integer"
+                },
+                "checked": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ResyncSong"
+                    }
+                },
+                "startedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResyncSong": {
+            "description": "Song whose details differ from the info service, with the differing fields, or that could not be checked",
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "songId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Revision": {
+            "description": "Song state after a change with the fields the change touched",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "snapshot": {
+                    "$ref": "#/definitions/models.Song"
+                },
+                "songId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RevisionDiff": {
+            "description": "Fields that differ between two revisions of a song",
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "songId": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Song": {
+            "description": "Song information about the account",
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "similarity": {
+                    "type": "number"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SongPatch": {
+            "description": "JSON merge patch of a song (RFC 7396): a field left out stays as it is and a null field is cleared; group and song can be changed but not cleared, and a cleared language goes back to the default",
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "models.SongsPage": {
+            "description": "Page of songs with the total count and the cursor of the next page",
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "type": "string"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Span": {
+            "description": "Occurrence of the phrase from character Start up to, not including, character End of a line, counted in Unicode code points",
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TrashPage": {
+            "description": "Page of trashed songs with the total count",
+            "type": "object",
+            "properties": {
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TrashedSong"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TrashedSong": {
+            "description": "Song moved to the trash and the time it was deleted",
+            "type": "object",
+            "properties": {
+                "deletedAt": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "similarity": {
+                    "type": "number"
+                },
+                "song": {
                     "type": "string"
                 },
                 "text": {
@@ -325,6 +2610,60 @@ const docTemplate = `{
                 }
             }
         },
+        "models.Verse": {
+            "description": "Verse of the lyrics, numbered from 1 across the whole song",
+            "type": "object",
+            "properties": {
+                "index": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.VerseMatch": {
+            "description": "Verse with every line of it and the lines that contain the phrase",
+            "type": "object",
+            "properties": {
+                "index": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "matches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LineMatch"
+                    }
+                }
+            }
+        },
+        "server.GroupName": {
+            "description": "New name of a group",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "server.MergeInto": {
+            "description": "Group to merge into",
+            "type": "object",
+            "properties": {
+                "into": {
+                    "type": "string"
+                }
+            }
+        },
         "server.NewID": {
             "description": "ID song",
             "type": "object",
@@ -334,6 +2673,45 @@ const docTemplate = `{
                 }
             }
         },
+        "server.Problem": {
+            "description": "Error response in the RFC 7807 problem details format with a stable error code",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "song_not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "failed to get song: song not found"
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/songs/42"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "server.Purged": {
+            "description": "Count of songs removed from the trash",
+            "type": "object",
+            "properties": {
+                "purged": {
+                    "type": "integer"
+                }
+            }
+        },
         "server.TextSong": {
             "description": "Text song",
             "type": "object",
@@ -0,0 +1,4 @@
+ALTER TABLE songs DROP CONSTRAINT IF EXISTS songs_group_song_key;
+ALTER TABLE songs DROP CONSTRAINT IF EXISTS songs_pkey;
+
+ALTER TABLE songs ADD PRIMARY KEY (song);
@@ -0,0 +1,5 @@
+ALTER TABLE songs DROP CONSTRAINT IF EXISTS songs_song_key;
+ALTER TABLE songs DROP CONSTRAINT IF EXISTS songs_pkey;
+
+ALTER TABLE songs ADD PRIMARY KEY (id);
+ALTER TABLE songs ADD CONSTRAINT songs_group_song_key UNIQUE ("group", song);
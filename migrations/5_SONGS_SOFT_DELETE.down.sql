@@ -0,0 +1,7 @@
+DELETE FROM songs WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS songs_deleted_at;
+DROP INDEX IF EXISTS songs_group_song_live_key;
+ALTER TABLE songs ADD CONSTRAINT songs_group_song_key UNIQUE ("group", song);
+
+ALTER TABLE songs DROP COLUMN IF EXISTS deleted_at;
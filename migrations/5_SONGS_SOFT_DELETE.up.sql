@@ -0,0 +1,8 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE songs DROP CONSTRAINT IF EXISTS songs_group_song_key;
+CREATE UNIQUE INDEX IF NOT EXISTS songs_group_song_live_key ON songs ("group", song)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS songs_deleted_at ON songs (deleted_at)
+    WHERE deleted_at IS NOT NULL;
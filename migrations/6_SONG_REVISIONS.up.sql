@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    action TEXT NOT NULL,
+    snapshot JSONB NOT NULL,
+    changes JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS song_revisions_song_id ON song_revisions (song_id, id);
+
+INSERT INTO song_revisions (song_id, action, snapshot, changes)
+    SELECT id, 'create', jsonb_build_object(
+        'id', id::text,
+        'group', COALESCE("group", ''),
+        'song', song,
+        'releaseDate', COALESCE(to_char(releasedate, 'DD.MM.YYYY'), ''),
+        'text', COALESCE(text, ''),
+        'link', COALESCE(link, '')
+    ), '[]'
+    FROM songs ORDER BY id;
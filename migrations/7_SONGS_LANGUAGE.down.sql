@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS songs_text_search;
+CREATE INDEX IF NOT EXISTS ind_serch_text ON songs
+    USING gin(make_tsvector(text));
+
+ALTER TABLE songs DROP COLUMN IF EXISTS language;
@@ -0,0 +1,5 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS language regconfig NOT NULL DEFAULT 'english';
+
+DROP INDEX IF EXISTS ind_serch_text;
+CREATE INDEX IF NOT EXISTS songs_text_search ON songs
+    USING gin(to_tsvector(language, COALESCE(text, '')));
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS songs_song_trgm;
+DROP INDEX IF EXISTS songs_group_trgm;
+
+DROP FUNCTION IF EXISTS fold(TEXT);
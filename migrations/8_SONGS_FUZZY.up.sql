@@ -0,0 +1,10 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- unaccent is only STABLE, which keeps it out of index expressions.
+CREATE OR REPLACE FUNCTION fold(value TEXT) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+    AS $$ SELECT lower(public.unaccent('public.unaccent'::regdictionary, value)) $$;
+
+CREATE INDEX IF NOT EXISTS songs_group_trgm ON songs USING gin(fold("group") gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS songs_song_trgm ON songs USING gin(fold(song) gin_trgm_ops);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    "group" TEXT NOT NULL UNIQUE
+);
+
+
+CREATE TABLE IF NOT EXISTS songs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    "group" TEXT REFERENCES groups("group"),
+    song TEXT NOT NULL UNIQUE,
+    releasedate TEXT,
+    text TEXT,
+    link TEXT
+);
+
+CREATE INDEX IF NOT EXISTS songs_song_releasedate ON songs (song, releasedate);
+CREATE INDEX IF NOT EXISTS songs_group ON songs ("group");
+CREATE INDEX IF NOT EXISTS songs_link ON songs (link);
+CREATE INDEX IF NOT EXISTS songs_song_group ON songs (song, "group");
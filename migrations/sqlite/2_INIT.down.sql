@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS songs_fts_update;
+DROP TRIGGER IF EXISTS songs_fts_delete;
+DROP TRIGGER IF EXISTS songs_fts_insert;
+DROP TABLE IF EXISTS songs_fts;
@@ -0,0 +1,21 @@
+CREATE VIRTUAL TABLE IF NOT EXISTS songs_fts USING fts5(
+    text,
+    content = 'songs',
+    content_rowid = 'id',
+    tokenize = 'porter unicode61'
+);
+
+INSERT INTO songs_fts(rowid, text) SELECT id, text FROM songs;
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_insert AFTER INSERT ON songs BEGIN
+    INSERT INTO songs_fts(rowid, text) VALUES (new.id, new.text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_delete AFTER DELETE ON songs BEGIN
+    INSERT INTO songs_fts(songs_fts, rowid, text) VALUES ('delete', old.id, old.text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_update AFTER UPDATE OF text ON songs BEGIN
+    INSERT INTO songs_fts(songs_fts, rowid, text) VALUES ('delete', old.id, old.text);
+    INSERT INTO songs_fts(rowid, text) VALUES (new.id, new.text);
+END;
@@ -0,0 +1,33 @@
+CREATE TABLE songs_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    "group" TEXT REFERENCES groups("group"),
+    song TEXT NOT NULL,
+    releasedate TEXT,
+    text TEXT,
+    link TEXT,
+    UNIQUE ("group", song)
+);
+
+INSERT INTO songs_new (id, "group", song, releasedate, text, link)
+    SELECT id, "group", song, releasedate, text, link FROM songs;
+
+DROP TABLE songs;
+ALTER TABLE songs_new RENAME TO songs;
+
+CREATE INDEX IF NOT EXISTS songs_song_releasedate ON songs (song, releasedate);
+CREATE INDEX IF NOT EXISTS songs_group ON songs ("group");
+CREATE INDEX IF NOT EXISTS songs_link ON songs (link);
+CREATE INDEX IF NOT EXISTS songs_song_group ON songs (song, "group");
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_insert AFTER INSERT ON songs BEGIN
+    INSERT INTO songs_fts(rowid, text) VALUES (new.id, new.text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_delete AFTER DELETE ON songs BEGIN
+    INSERT INTO songs_fts(songs_fts, rowid, text) VALUES ('delete', old.id, old.text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_update AFTER UPDATE OF text ON songs BEGIN
+    INSERT INTO songs_fts(songs_fts, rowid, text) VALUES ('delete', old.id, old.text);
+    INSERT INTO songs_fts(rowid, text) VALUES (new.id, new.text);
+END;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    song_id INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    action TEXT NOT NULL,
+    snapshot TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS song_revisions_song_id ON song_revisions (song_id, id);
+
+INSERT INTO song_revisions (song_id, action, snapshot, changes, created_at)
+    SELECT id, 'create', json_object(
+        'id', CAST(id AS TEXT),
+        'group', COALESCE("group", ''),
+        'song', song,
+        'releaseDate', COALESCE(strftime('%d.%m.%Y', releasedate), ''),
+        'text', COALESCE(text, ''),
+        'link', COALESCE(link, '')
+    ), '[]', CURRENT_TIMESTAMP
+    FROM songs ORDER BY id;
@@ -0,0 +1 @@
+ALTER TABLE songs ADD COLUMN language TEXT NOT NULL DEFAULT 'english';
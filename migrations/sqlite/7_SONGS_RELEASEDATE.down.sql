@@ -0,0 +1 @@
+DROP INDEX IF EXISTS songs_releasedate;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS songs_releasedate ON songs (releasedate)
+    WHERE deleted_at IS NULL;
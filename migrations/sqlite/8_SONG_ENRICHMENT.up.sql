@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS song_enrichment (
+    song_id INTEGER PRIMARY KEY REFERENCES songs(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    detect_language BOOLEAN NOT NULL DEFAULT false,
+    last_error TEXT,
+    run_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS song_enrichment_due ON song_enrichment (run_at)
+    WHERE status IN ('pending', 'running');